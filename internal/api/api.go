@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/currency"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"go.uber.org/zap"
+)
+
+// DefaultRequestTimeout — общий бюджет времени на один запрос к ценам по умолчанию.
+const DefaultRequestTimeout = 5 * time.Second
+
+// maxRatePairs ограничивает размер ids × vs в одном запросе /rates.
+const maxRatePairs = 100
+
+// DefaultRatePairs — пары, которые /rates отдаёт, если ids/vs не указаны.
+var DefaultRatePairs = []price.Pair{
+	{ID: "bitcoin", VS: "usd"},
+	{ID: "ethereum", VS: "usd"},
+	{ID: "usd", VS: "rub"},
+}
+
+// Handlers содержит HTTP-обработчики публичного API.
+type Handlers struct {
+	prices price.PriceClient
+	svc    *currency.Service
+	logger *zap.SugaredLogger
+
+	// меняются на лету (см. SetRequestTimeout, SetRatePairs)
+	requestTimeout atomic.Int64 // time.Duration
+	ratePairs      atomic.Pointer[[]price.Pair]
+}
+
+// Option настраивает Handlers.
+type Option func(*Handlers)
+
+// WithRequestTimeout задаёт бюджет времени на один запрос к ценам.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(h *Handlers) { h.SetRequestTimeout(d) }
+}
+
+// WithRatePairs задаёт пары, которые /rates отдаёт без ids/vs.
+func WithRatePairs(pairs []price.Pair) Option {
+	return func(h *Handlers) { h.SetRatePairs(pairs) }
+}
+
+func NewHandlers(prices price.PriceClient, svc *currency.Service, logger *zap.SugaredLogger, opts ...Option) *Handlers {
+	h := &Handlers{prices: prices, svc: svc, logger: logger}
+	h.SetRequestTimeout(DefaultRequestTimeout)
+	h.SetRatePairs(DefaultRatePairs)
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// SetRequestTimeout меняет бюджет времени на запрос на лету (см. WithRequestTimeout).
+func (h *Handlers) SetRequestTimeout(d time.Duration) { h.requestTimeout.Store(int64(d)) }
+
+// SetRatePairs меняет пары /rates по умолчанию на лету (см. WithRatePairs).
+func (h *Handlers) SetRatePairs(pairs []price.Pair) { h.ratePairs.Store(&pairs) }
+
+func (h *Handlers) timeout() time.Duration { return time.Duration(h.requestTimeout.Load()) }
+
+// priceResponse — тело ответа GET /v1/price/{id}/{vs}.
+type priceResponse struct {
+	ID                string    `json:"id"`
+	VS                string    `json:"vs"`
+	Price             float64   `json:"price"`
+	Source            string    `json:"source,omitempty"`  // провайдер; пусто для записей старого формата
+	Sources           int       `json:"sources,omitempty"` // число сошедшихся провайдеров для агрегированной цены
+	FetchedAt         time.Time `json:"fetched_at,omitzero"`
+	UpstreamUpdatedAt time.Time `json:"upstream_updated_at,omitzero"`
+	Cached            bool      `json:"cached"`
+	Stale             bool      `json:"stale,omitempty"`
+	AgeSec            float64   `json:"age_seconds,omitempty"` // только для устаревших цен
+}
+
+// Price обрабатывает GET /v1/price/{id}/{vs}.
+// id — монета или фиат (для курса валют), vs — фиат.
+// 400 — некорректный id/vs, 404 — неизвестная монета или валюта,
+// ошибки провайдера — по errorStatus (429/502/503/504).
+func (h *Handlers) Price(w http.ResponseWriter, r *http.Request) {
+	id, vs := r.PathValue("id"), r.PathValue("vs")
+	if !asset.ValidID(id) || !asset.ValidID(vs) {
+		writeError(w, http.StatusBadRequest, "invalid id or vs")
+		return
+	}
+	if _, ok := asset.Lookup(id); !ok {
+		writeError(w, http.StatusNotFound, "unknown id "+id)
+		return
+	}
+	if !asset.IsFiat(vs) {
+		writeError(w, http.StatusNotFound, "unsupported vs currency "+vs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout())
+	defer cancel()
+
+	q, err := price.FetchQuote(ctx, h.prices, id, vs)
+	if err != nil {
+		h.logger.Errorw("price: error getting price", "id", id, "vs", vs, "error", err)
+		var rl *price.RateLimitError
+		if errors.As(err, &rl) && rl.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(rl.RetryAfter)))
+		}
+		writeError(w, errorStatus(err), errorMessage(err))
+		return
+	}
+
+	resp := priceResponse{
+		ID:                id,
+		VS:                vs,
+		Price:             q.Price,
+		Source:            q.Source,
+		Sources:           q.Sources,
+		FetchedAt:         q.FetchedAt,
+		UpstreamUpdatedAt: q.UpstreamUpdatedAt,
+		Cached:            q.Cached,
+		Stale:             q.Stale,
+	}
+	if q.Stale {
+		resp.AgeSec = q.Age(time.Now()).Round(time.Second).Seconds()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Rates обрабатывает GET /rates?ids=bitcoin,ethereum&vs=usd,eur и возвращает
+// цены для всех пар ids × vs в виде {id: {vs: price}}.
+// Без параметров используются пары WithRatePairs (по умолчанию DefaultRatePairs).
+func (h *Handlers) Rates(w http.ResponseWriter, r *http.Request) {
+	pairs, err := ratePairs(r.URL.Query(), *h.ratePairs.Load())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout())
+	defer cancel()
+
+	results := h.svc.GetMany(ctx, pairs)
+	data := results.Prices()
+	failed := results.Failed()
+	if len(failed) == 0 {
+		writeJSON(w, http.StatusOK, data)
+		return
+	}
+
+	h.logger.Errorw("rates: error getting prices", "failed", len(failed), "error", failed.Err())
+	errs := make(map[string]map[string]string)
+	for _, r := range failed {
+		if errs[r.Pair.ID] == nil {
+			errs[r.Pair.ID] = make(map[string]string)
+		}
+		errs[r.Pair.ID][r.Pair.VS] = errorMessage(r.Err)
+	}
+
+	status := http.StatusPartialContent
+	if len(data) == 0 {
+		status = http.StatusBadGateway
+	}
+	writeJSON(w, status, map[string]any{
+		"data":   data,
+		"errors": errs,
+	})
+}
+
+// ratePairs разбирает ids/vs из query. Оба параметра либо заданы, либо нет;
+// без них возвращаются defaults.
+func ratePairs(q url.Values, defaults []price.Pair) ([]price.Pair, error) {
+	ids, vs := splitList(q.Get("ids")), splitList(q.Get("vs"))
+	if len(ids) == 0 && len(vs) == 0 {
+		return defaults, nil
+	}
+	if len(ids) == 0 || len(vs) == 0 {
+		return nil, errors.New("both ids and vs must be set")
+	}
+	for _, id := range ids {
+		if _, ok := asset.Lookup(id); !ok {
+			return nil, fmt.Errorf("unknown id %q", id)
+		}
+	}
+	for _, v := range vs {
+		if !asset.IsFiat(v) {
+			return nil, fmt.Errorf("unsupported vs currency %q", v)
+		}
+	}
+	if len(ids)*len(vs) > maxRatePairs {
+		return nil, fmt.Errorf("too many pairs: at most %d allowed", maxRatePairs)
+	}
+	return currency.CrossPairs(ids, vs), nil
+}
+
+// splitList разбирает "a, b,,a" в [a b]: пустые элементы и дубликаты отбрасываются.
+func splitList(s string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" || seen[part] {
+			continue
+		}
+		seen[part] = true
+		out = append(out, part)
+	}
+	return out
+}
+
+// errorStatus сопоставляет ошибку клиента цен HTTP-статусу.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, price.ErrUnknownID), errors.Is(err, price.ErrUnknownVS):
+		return http.StatusNotFound
+	case errors.Is(err, price.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, price.ErrUpstreamUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// errorMessage возвращает безопасное для клиента описание ошибки
+// (подробности остаются в логах).
+func errorMessage(err error) string {
+	switch {
+	case errors.Is(err, price.ErrUnknownID):
+		return "unknown id"
+	case errors.Is(err, price.ErrUnknownVS):
+		return "unknown vs currency"
+	case errors.Is(err, price.ErrRateLimited):
+		return "rate limited by upstream"
+	case errors.Is(err, price.ErrUpstreamUnavailable):
+		return "upstream unavailable"
+	case errors.Is(err, price.ErrBadPayload):
+		return "bad upstream payload"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "upstream error"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// retryAfterSeconds переводит паузу в целые секунды для заголовка Retry-After,
+// округляя вверх: клиент не должен прийти раньше, чем лимит освободится.
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
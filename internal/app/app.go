@@ -0,0 +1,517 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/api"
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/cache"
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/config"
+	"github.com/boxdancer/go-currency-tracker/internal/currency"
+	"github.com/boxdancer/go-currency-tracker/internal/leader"
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/poller"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total HTTP requests processed, labeled by method, path and status",
+		},
+		[]string{"method", "path", "status"},
+	)
+
+	httpDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request duration in seconds, labeled by method and path",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "path"},
+	)
+)
+
+func init() {
+	// Регистрируем метрики в дефолтном реестре
+	prometheus.MustRegister(httpRequests, httpDuration)
+}
+
+// statusRecorder — вспомогательный writer, чтобы сохранить статус-код ответа
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func instrumentHandler(path string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+		duration := time.Since(start).Seconds()
+		httpDuration.WithLabelValues(r.Method, path).Observe(duration)
+		httpRequests.WithLabelValues(r.Method, path, strconv.Itoa(rec.status)).Inc()
+	}
+}
+
+// configWatchInterval — как часто проверяется файл конфигурации при WithReload.
+const configWatchInterval = 2 * time.Second
+
+// App — приложение, собранное из config.Config: кэш, цепочка клиентов цен,
+// сервис, фоновое обновление и HTTP-обработчики.
+type App struct {
+	metrics observability.Metrics
+	logger  *zap.SugaredLogger
+
+	mu  sync.Mutex // защищает cfg и сериализует Reload
+	cfg config.Config
+
+	// перечитывание конфигурации на лету (см. WithReload, WithLogLevel)
+	load     func() (config.Config, error)
+	cfgPath  string
+	level    zap.AtomicLevel
+	hasLevel bool
+
+	cache   cache.Cache
+	tiered  *cache.TieredCache // nil, если кэш не tiered
+	limiter *client.RateLimitedClient
+	router  *client.Router
+	agg     *client.Aggregator
+	fo      *client.Failover
+	prices  *client.CachedPriceClient
+	service *currency.Service
+	poller  *poller.Poller // nil, если фоновое обновление выключено
+	elector *leader.Elector
+	api     *api.Handlers
+	handler http.Handler
+}
+
+// Option настраивает App.
+type Option func(*App)
+
+// WithReload включает перечитывание конфигурации через load по SIGHUP и, если path
+// не пуст, при изменении этого файла (см. Reload). load не должен проверять конфигурацию
+// (config.Read): Reload проверяет её сам, вернув поля, требующие перезапуска.
+func WithReload(load func() (config.Config, error), path string) Option {
+	return func(a *App) {
+		a.load = load
+		a.cfgPath = path
+	}
+}
+
+// WithLogLevel передаёт уровень логгера, который Reload меняет вслед за log.level.
+func WithLogLevel(level zap.AtomicLevel) Option {
+	return func(a *App) {
+		a.level = level
+		a.hasLevel = true
+	}
+}
+
+// New собирает приложение по cfg. metrics может быть nil — тогда будет использован noop.
+// Фоновые задачи и HTTP-сервер запускает Run.
+func New(cfg config.Config, m observability.Metrics, logger *zap.SugaredLogger, opts ...Option) (*App, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	a := &App{cfg: cfg, metrics: m, logger: logger}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	freshness, byClass := freshnessOf(cfg), classFreshnessOf(cfg)
+	retention := freshness.Retention()
+	for _, f := range byClass {
+		retention = max(retention, f.Retention())
+	}
+	redisCache := a.newCache(retention)
+
+	// cache -> провайдеры (CoinGecko: retry -> circuit breaker -> rate limiter; aggregate; failover) -> router -> cached client -> service
+	cg := client.NewCoinGeckoClient(cfg.CoinGecko.Timeout)
+	cg.SetBaseURL(cfg.CoinGecko.BaseURL)
+	cg.SetRetryPolicy(client.DefaultRetryPolicy())
+	cg.SetMetrics(m)
+	breaker := client.NewCircuitBreaker(client.CoinGeckoName, cg, client.DefaultBreakerConfig(), m)
+	// limiter снаружи breaker: локальные отказы по лимиту не должны размыкать цепь
+	a.limiter = client.NewRateLimitedClient(client.CoinGeckoName, breaker, rateLimitOf(cfg), m)
+	// повторы идут внутри limiter, поэтому каждый из них берёт свой токен
+	cg.SetRetryLimiter(a.limiter)
+	providers, err := a.providers()
+	if err != nil {
+		return nil, fmt.Errorf("price providers: %w", err)
+	}
+	router, err := client.NewRouter(providers, cfg.Routing.Rules, m)
+	if err != nil {
+		return nil, fmt.Errorf("price router: %w", err)
+	}
+	a.router = router
+	a.prices = client.NewCachedPriceClient(a.router, a.cache, m,
+		client.WithFreshness(freshness),
+		client.WithClassFreshness(byClass),
+		client.WithNegativeTTL(cfg.Cache.NegativeTTL),
+	)
+	a.service = currency.NewService(a.prices,
+		currency.WithConcurrency(cfg.Service.Concurrency),
+		currency.WithPairTimeout(cfg.Service.PairTimeout),
+	)
+
+	// фоновое обновление; с Redis обновляет только реплика, держащая lease, иначе — каждый инстанс
+	if cfg.Poller.Enabled {
+		var opts []poller.Option
+		if redisCache != nil {
+			electionCfg := leader.Config{
+				TTL:           cfg.Leader.TTL,
+				RenewInterval: cfg.Leader.RenewInterval,
+				RetryInterval: cfg.Leader.RetryInterval,
+			}
+			a.elector = leader.NewElector(cfg.Leader.Key, redisCache.Lock(cfg.Leader.Key, cfg.Leader.TTL), electionCfg, m, logger)
+			opts = append(opts, poller.WithLeader(a.elector))
+		}
+		a.poller = poller.New(a.prices, pollerConfigOf(cfg), m, logger, opts...)
+	}
+
+	a.api = api.NewHandlers(a.prices, a.service, a.logger,
+		api.WithRequestTimeout(cfg.HTTP.RequestTimeout),
+		api.WithRatePairs(cfg.Rates.DefaultPairs),
+	)
+	a.handler = a.routes()
+	return a, nil
+}
+
+// exchangeClient — общие методы клиентов бирж.
+type exchangeClient interface {
+	price.PriceClient
+	SetRetryPolicy(client.RetryPolicy)
+	SetMetrics(observability.Metrics)
+}
+
+// providers собирает провайдеров цен для роутера по именам из config.Providers.
+// Биржи, агрегатор и failover запрашиваются только по правилам routing.rules, поэтому создаются всегда.
+func (a *App) providers() (map[string]price.PriceClient, error) {
+	cfg, m := a.cfg, a.metrics
+	providers := map[string]price.PriceClient{client.CoinGeckoName: a.limiter}
+
+	binance := client.NewBinanceClient(cfg.Exchanges.Timeout)
+	binance.SetBaseURL(cfg.Exchanges.BinanceURL)
+	kraken := client.NewKrakenClient(cfg.Exchanges.Timeout)
+	kraken.SetBaseURL(cfg.Exchanges.KrakenURL)
+	coinbase := client.NewCoinbaseClient(cfg.Exchanges.Timeout)
+	coinbase.SetBaseURL(cfg.Exchanges.CoinbaseURL)
+	for name, ex := range map[string]exchangeClient{
+		client.BinanceName:  binance,
+		client.KrakenName:   kraken,
+		client.CoinbaseName: coinbase,
+	} {
+		ex.SetRetryPolicy(client.DefaultRetryPolicy())
+		ex.SetMetrics(m)
+		providers[name] = client.NewCircuitBreaker(name, ex, client.DefaultBreakerConfig(), m)
+	}
+
+	if cfg.FX.Enabled {
+		fx := client.NewFXClient(cfg.FX.Timeout)
+		fx.SetFeedURL(client.ECBName, cfg.FX.ECBURL)
+		fx.SetFeedURL(client.CBRName, cfg.FX.CBRURL)
+		fx.SetRetryPolicy(client.DefaultRetryPolicy())
+		fx.SetMetrics(m)
+		providers[client.FXName] = fx
+	}
+
+	sources := make(map[string]price.PriceClient, len(cfg.Aggregate.Providers))
+	for _, name := range cfg.Aggregate.Providers {
+		sources[name] = providers[name]
+	}
+	a.agg = client.NewAggregator(sources, aggregateConfigOf(cfg), m)
+	providers[client.AggregateName] = a.agg
+
+	// failover может включать и aggregate; провайдеры проверены в Validate
+	fo, err := client.NewFailover(cfg.Failover.Providers, providers, failoverConfigOf(cfg), m)
+	if err != nil {
+		return nil, err
+	}
+	a.fo = fo
+	providers[client.FailoverName] = fo
+	return providers, nil
+}
+
+func freshnessOf(cfg config.Config) client.Freshness {
+	return client.Freshness{
+		FreshFor: cfg.Cache.FreshFor,
+		StaleFor: cfg.Cache.StaleFor,
+		MaxStale: cfg.Cache.MaxStale,
+	}
+}
+
+func classFreshnessOf(cfg config.Config) map[asset.Class]client.Freshness {
+	out := make(map[asset.Class]client.Freshness, len(cfg.Cache.ClassFreshness))
+	for _, cf := range cfg.Cache.ClassFreshness {
+		out[cf.Class] = cf.Freshness
+	}
+	return out
+}
+
+func rateLimitOf(cfg config.Config) client.RateLimit {
+	return client.RateLimit{Rate: cfg.CoinGecko.Rate, Burst: cfg.CoinGecko.Burst, Wait: true}
+}
+
+func aggregateConfigOf(cfg config.Config) client.AggregateConfig {
+	return client.AggregateConfig{
+		MinSources:   cfg.Aggregate.MinSources,
+		MaxDeviation: cfg.Aggregate.MaxDeviation,
+		Method:       client.AggregateMethod(cfg.Aggregate.Method),
+	}
+}
+
+func failoverConfigOf(cfg config.Config) client.FailoverConfig {
+	return client.FailoverConfig{
+		Decay:       cfg.Failover.Decay,
+		DemoteBelow: cfg.Failover.DemoteBelow,
+		DemoteFor:   cfg.Failover.DemoteFor,
+	}
+}
+
+func pollerConfigOf(cfg config.Config) poller.Config {
+	return poller.Config{
+		Pairs:    cfg.PollerPairs(),
+		Interval: cfg.Poller.Interval,
+		Jitter:   cfg.Poller.Jitter,
+		Timeout:  cfg.Poller.Timeout,
+	}
+}
+
+// Config возвращает действующую конфигурацию (с учётом применённых Reload).
+func (a *App) Config() config.Config {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg
+}
+
+// Reload перечитывает конфигурацию и применяет на лету поля, которые можно менять
+// без перезапуска: пары, окна свежести и negative TTL, лимит CoinGecko, параметры
+// сервиса и poller, правила маршрутизации, агрегации и failover, таймаут запроса
+// и уровень логов. Изменения остальных полей отбрасываются с предупреждением в логе,
+// и только после этого конфигурация проверяется через Validate: неверное значение
+// такого поля не мешает применить остальные. При ошибке загрузки или проверки
+// действующая конфигурация не меняется. Результат отражается
+// в метрике config_reloads_total.
+func (a *App) Reload() error {
+	if a.load == nil {
+		return errors.New("config reload is not configured")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	next, err := a.load()
+	if err == nil {
+		var rejected []string
+		next, rejected = config.Reloadable(a.cfg, next)
+		for _, name := range rejected {
+			a.logger.Warnw("config reload: field cannot change at runtime, restart to apply", "field", name)
+		}
+		err = next.Validate()
+	}
+	if err != nil {
+		a.metrics.ConfigReload(false)
+		a.logger.Errorw("config reload failed, keeping current config", "error", err)
+		return err
+	}
+
+	a.apply(next)
+	a.cfg = next
+	a.metrics.ConfigReload(true)
+	a.logger.Infof("config reloaded, effective config:\n%s", next)
+	return nil
+}
+
+// apply переключает работающие компоненты на безопасные поля cfg.
+func (a *App) apply(cfg config.Config) {
+	if a.hasLevel {
+		if lvl, err := zapcore.ParseLevel(cfg.Log.Level); err == nil {
+			a.level.SetLevel(lvl)
+		}
+	}
+	a.prices.SetFreshness(freshnessOf(cfg))
+	a.prices.SetClassFreshness(classFreshnessOf(cfg))
+	a.prices.SetNegativeTTL(cfg.Cache.NegativeTTL)
+	a.limiter.SetLimit(rateLimitOf(cfg))
+	if err := a.router.SetRoutes(cfg.Routing.Rules); err != nil { // провайдеры проверены в Validate
+		a.logger.Errorw("config reload: routing rules not applied", "error", err)
+	}
+	a.agg.SetConfig(aggregateConfigOf(cfg))
+	a.fo.SetConfig(failoverConfigOf(cfg))
+	a.service.SetConcurrency(cfg.Service.Concurrency)
+	a.service.SetPairTimeout(cfg.Service.PairTimeout)
+	a.api.SetRequestTimeout(cfg.HTTP.RequestTimeout)
+	a.api.SetRatePairs(cfg.Rates.DefaultPairs)
+	if a.poller != nil {
+		a.poller.SetConfig(pollerConfigOf(cfg))
+	}
+}
+
+// newCache создаёт кэш по cfg.Cache и возвращает Redis-кэш (для redis и tiered), иначе nil.
+func (a *App) newCache(ttl time.Duration) *cache.RedisCache {
+	c := a.cfg.Cache
+	switch a.cfg.CacheBackend() {
+	case "redis":
+		redisCache := cache.NewRedisCache(c.RedisAddr, ttl, a.logger)
+		a.cache = redisCache
+		return redisCache
+	case "tiered":
+		redisCache := cache.NewRedisCache(c.RedisAddr, ttl, a.logger)
+		a.tiered = cache.NewTieredCache(
+			cache.NewMemoryCache(c.MaxEntries, c.L1TTL, a.metrics),
+			redisCache,
+			a.metrics,
+			cache.WithInvalidation(redisCache.Invalidator(cache.DefaultInvalidationChannel)),
+		)
+		a.cache = a.tiered
+		a.logger.Infow("using tiered cache", "l1_max_entries", c.MaxEntries, "l1_ttl", c.L1TTL, "ttl", ttl)
+		return redisCache
+	default:
+		a.cache = cache.NewMemoryCache(c.MaxEntries, ttl, a.metrics)
+		a.logger.Infow("using in-memory cache", "max_entries", c.MaxEntries, "ttl", ttl)
+		return nil
+	}
+}
+
+func (a *App) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", instrumentHandler("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, "pong")
+	}))
+
+	h := a.api
+	mux.HandleFunc("GET /v1/price/{id}/{vs}", instrumentHandler("/v1/price", h.Price))
+	mux.HandleFunc("GET /rates", instrumentHandler("/rates", h.Rates))
+
+	if a.elector != nil {
+		mux.Handle("GET /debug/leader", a.elector)
+	}
+
+	// Prometheus metrics endpoint (scrape target)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// Handler возвращает HTTP-обработчик приложения (для тестов — без запуска сервера).
+func (a *App) Handler() http.Handler { return a.handler }
+
+// Run запускает фоновые задачи и HTTP-сервер на cfg.HTTP.Addr и работает до отмены ctx.
+// Затем останавливает сервер (не дольше cfg.HTTP.ShutdownTimeout) и дожидается фоновых задач:
+// лидер при этом освобождает lease для других реплик.
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	var background sync.WaitGroup
+	a.startBackground(ctx, &background)
+
+	cfg := a.Config() // addr и shutdown_timeout на лету не меняются
+	srv := &http.Server{
+		Addr:    cfg.HTTP.Addr,
+		Handler: a.handler,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		a.logger.Infof("starting server on %s", cfg.HTTP.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done(): // ждём сигнал
+	case err = <-serveErr:
+		err = fmt.Errorf("listen: %w", err)
+	}
+	a.logger.Info("shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+		a.logger.Errorf("server shutdown error: %v", shutdownErr)
+	} else {
+		a.logger.Info("server stopped gracefully")
+	}
+	stop()
+	background.Wait()
+	return err
+}
+
+// startBackground запускает инвалидацию L1, выборы лидера, фоновое обновление
+// и перечитывание конфигурации до отмены ctx.
+func (a *App) startBackground(ctx context.Context, wg *sync.WaitGroup) {
+	run := func(name string, fn func(context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(ctx); err != nil {
+				a.logger.Warnw(name+" stopped", "error", err)
+			}
+		}()
+	}
+	if a.tiered != nil {
+		run("L1 invalidation", a.tiered.Listen)
+	}
+	if a.elector != nil {
+		run("leader election", a.elector.Run)
+	}
+	if a.poller != nil {
+		run("poller", a.poller.Run)
+	}
+	if a.load != nil {
+		run("config reload on SIGHUP", a.reloadOnSignal)
+		if a.cfgPath != "" {
+			run("config file watch", func(ctx context.Context) error {
+				return config.WatchFile(ctx, a.cfgPath, configWatchInterval, func() {
+					a.logger.Infow("config file changed, reloading", "path", a.cfgPath)
+					_ = a.Reload()
+				})
+			})
+		}
+	}
+}
+
+// reloadOnSignal вызывает Reload на каждый SIGHUP до отмены ctx.
+func (a *App) reloadOnSignal(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			a.logger.Info("SIGHUP received, reloading config")
+			_ = a.Reload()
+		}
+	}
+}
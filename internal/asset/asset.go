@@ -0,0 +1,72 @@
+package asset
+
+import "regexp"
+
+// Class — класс актива.
+type Class string
+
+const (
+	Crypto     Class = "crypto"
+	Stablecoin Class = "stablecoin"
+	Fiat       Class = "fiat"
+)
+
+// Asset описывает известный сервису актив.
+// ID совпадает с идентификатором CoinGecko для крипты и с ISO-кодом (в нижнем регистре) для фиата.
+type Asset struct {
+	ID     string
+	Symbol string
+	Class  Class
+}
+
+var registry = map[string]Asset{
+	// crypto
+	"bitcoin":     {ID: "bitcoin", Symbol: "BTC", Class: Crypto},
+	"ethereum":    {ID: "ethereum", Symbol: "ETH", Class: Crypto},
+	"binancecoin": {ID: "binancecoin", Symbol: "BNB", Class: Crypto},
+	"solana":      {ID: "solana", Symbol: "SOL", Class: Crypto},
+	"ripple":      {ID: "ripple", Symbol: "XRP", Class: Crypto},
+	"cardano":     {ID: "cardano", Symbol: "ADA", Class: Crypto},
+	"dogecoin":    {ID: "dogecoin", Symbol: "DOGE", Class: Crypto},
+	"litecoin":    {ID: "litecoin", Symbol: "LTC", Class: Crypto},
+	"polkadot":    {ID: "polkadot", Symbol: "DOT", Class: Crypto},
+	"tron":        {ID: "tron", Symbol: "TRX", Class: Crypto},
+
+	// stablecoins
+	"tether":   {ID: "tether", Symbol: "USDT", Class: Stablecoin},
+	"usd-coin": {ID: "usd-coin", Symbol: "USDC", Class: Stablecoin},
+
+	// fiat
+	"usd": {ID: "usd", Symbol: "USD", Class: Fiat},
+	"eur": {ID: "eur", Symbol: "EUR", Class: Fiat},
+	"rub": {ID: "rub", Symbol: "RUB", Class: Fiat},
+	"gbp": {ID: "gbp", Symbol: "GBP", Class: Fiat},
+	"jpy": {ID: "jpy", Symbol: "JPY", Class: Fiat},
+	"cny": {ID: "cny", Symbol: "CNY", Class: Fiat},
+	"chf": {ID: "chf", Symbol: "CHF", Class: Fiat},
+}
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// ValidID сообщает, похожа ли строка на идентификатор актива (синтаксически).
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Lookup возвращает актив по идентификатору.
+func Lookup(id string) (Asset, bool) {
+	a, ok := registry[id]
+	return a, ok
+}
+
+// IsCoin сообщает, является ли id известной криптовалютой (включая стейблкоины).
+func IsCoin(id string) bool {
+	a, ok := registry[id]
+	return ok && a.Class != Fiat
+}
+
+// IsFiat сообщает, является ли id известной фиатной валютой.
+func IsFiat(id string) bool {
+	a, ok := registry[id]
+	return ok && a.Class == Fiat
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+)
+
+// MemoryName — имя in-memory кэша в метриках.
+const MemoryName = "memory"
+
+// MemoryCache — in-process реализация Cache: LRU с ограничением по числу записей и TTL.
+// Подходит для локальной разработки, одного инстанса и тестов без Redis.
+// Истёкшие записи удаляются при обращении к ним или вытесняются по LRU.
+type MemoryCache struct {
+	maxEntries int
+	ttl        time.Duration
+	metrics    observability.Metrics
+
+	mu    sync.Mutex
+	ll    *list.List // от недавно использованных к давно использованным
+	items map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // zero — без TTL
+}
+
+// NewMemoryCache создаёт кэш на maxEntries записей (<= 0 — без ограничения) с TTL ttl
+// (<= 0 — записи не истекают). metrics может быть nil — тогда будет использован noop.
+func NewMemoryCache(maxEntries int, ttl time.Duration, m observability.Metrics) *MemoryCache {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		metrics:    m,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.get(key)
+	if !ok {
+		return "", ErrMiss
+	}
+	return v, nil
+}
+
+func (c *MemoryCache) MGet(_ context.Context, keys []string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if v, ok := c.get(key); ok {
+			out[key] = v
+		}
+	}
+	return out, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte) error {
+	return c.SetWithTTL(ctx, key, value, c.ttl)
+}
+
+func (c *MemoryCache) SetWithTTL(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return ErrInvalidTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+	return nil
+}
+
+func (c *MemoryCache) MSet(_ context.Context, values map[string][]byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return ErrInvalidTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range values {
+		c.set(key, value, ttl)
+	}
+	return nil
+}
+
+// get возвращает значение живой записи и отмечает её использование. Вызывается под c.mu.
+func (c *MemoryCache) get(key string) (string, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	e := el.Value.(*memoryEntry)
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		c.remove(el, "expired")
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// set сохраняет запись (ttl <= 0 — без истечения) и вытесняет лишние. Вызывается под c.mu.
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*memoryEntry)
+		e.value, e.expiresAt = string(value), expiresAt
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: string(value), expiresAt: expiresAt})
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back(), "capacity")
+	}
+}
+
+// Len возвращает количество записей (включая истёкшие, но ещё не удалённые).
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// remove удаляет запись и учитывает вытеснение (reason: capacity, expired). Вызывается под c.mu.
+func (c *MemoryCache) remove(el *list.Element, reason string) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+	c.metrics.CacheEviction(MemoryName, reason)
+}
+
+// Delete удаляет запись; отсутствие ключа ошибкой не считается.
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Clear удаляет все записи.
+func (c *MemoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	clear(c.items)
+}
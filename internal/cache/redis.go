@@ -2,15 +2,34 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+// ErrMiss возвращается Cache.Get, если ключа нет (или запись истекла).
+var ErrMiss = errors.New("cache miss")
+
+// ErrInvalidTTL возвращается при записи с отрицательным TTL.
+var ErrInvalidTTL = errors.New("cache: negative ttl")
+
+// Cache — хранилище строковых значений с TTL, заданным реализацией.
+// Get возвращает ErrMiss, если ключа нет.
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
+	// MGet возвращает значения найденных ключей; отсутствующих ключей в результате нет.
+	MGet(ctx context.Context, keys []string) (map[string]string, error)
+	// Set сохраняет значение с TTL реализации.
 	Set(ctx context.Context, key string, value []byte) error
+	// SetWithTTL сохраняет значение с собственным TTL; ttl == 0 — без истечения,
+	// отрицательный ttl отклоняется с ErrInvalidTTL.
+	SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// MSet сохраняет несколько значений с TTL ttl (как в SetWithTTL).
+	MSet(ctx context.Context, values map[string][]byte, ttl time.Duration) error
+	// Delete удаляет ключ; отсутствие ключа ошибкой не считается.
+	Delete(ctx context.Context, key string) error
 }
 
 type RedisCache struct {
@@ -41,16 +60,74 @@ func NewRedisCache(addr string, ttl time.Duration, logger *zap.SugaredLogger) *R
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 	val, err := r.client.Get(ctx, key).Result()
-	if err != nil && err != redis.Nil {
+	if errors.Is(err, redis.Nil) {
+		return "", ErrMiss
+	}
+	if err != nil {
 		r.logger.Warnw("redis get failed", "key", key, "error", err)
 	}
 	return val, err
 }
 
+// MGet получает все ключи одной командой MGET.
+func (r *RedisCache) MGet(ctx context.Context, keys []string) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return out, nil
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		r.logger.Warnw("redis mget failed", "keys", len(keys), "error", err)
+		return out, err
+	}
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			out[keys[i]] = s
+		}
+	}
+	return out, nil
+}
+
 func (r *RedisCache) Set(ctx context.Context, key string, value []byte) error {
-	err := r.client.Set(ctx, key, value, r.ttl).Err()
+	return r.SetWithTTL(ctx, key, value, r.ttl)
+}
+
+func (r *RedisCache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		// для go-redis -1 означает KEEPTTL: запись молча осталась бы без истечения
+		return ErrInvalidTTL
+	}
+	err := r.client.Set(ctx, key, value, ttl).Err()
 	if err != nil {
 		r.logger.Warnw("redis set failed", "key", key, "error", err)
 	}
 	return err
 }
+
+// MSet пишет значения одним pipeline: у MSET нет TTL, поэтому используется SET на каждый ключ.
+func (r *RedisCache) MSet(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return ErrInvalidTTL
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			pipe.Set(ctx, key, value, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Warnw("redis mset failed", "keys", len(values), "error", err)
+	}
+	return err
+}
+
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	err := r.client.Del(ctx, key).Err()
+	if err != nil {
+		r.logger.Warnw("redis del failed", "key", key, "error", err)
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultInvalidationChannel — канал Redis pub/sub для инвалидации L1.
+const DefaultInvalidationChannel = "cache:invalidate"
+
+// RedisInvalidator — Invalidator поверх Redis pub/sub.
+// Сообщение имеет вид "<id реплики> <ключ>", чтобы реплика пропускала свои же сообщения.
+type RedisInvalidator struct {
+	client   *redis.Client
+	channel  string
+	instance string
+}
+
+// Invalidator возвращает RedisInvalidator, использующий соединение кэша.
+func (r *RedisCache) Invalidator(channel string) *RedisInvalidator {
+	return &RedisInvalidator{client: r.client, channel: channel, instance: newInstanceID()}
+}
+
+func (i *RedisInvalidator) Publish(ctx context.Context, key string) error {
+	return i.client.Publish(ctx, i.channel, i.instance+" "+key).Err()
+}
+
+func (i *RedisInvalidator) Subscribe(ctx context.Context, ready func(), fn func(key string)) error {
+	sub := i.client.Subscribe(ctx, i.channel)
+	defer func() { _ = sub.Close() }()
+
+	// дожидаемся подтверждения подписки, чтобы не терять ошибку соединения
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+	ready()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			from, key, found := strings.Cut(msg.Payload, " ")
+			if !found || from == i.instance {
+				continue
+			}
+			fn(key)
+		}
+	}
+}
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// renewScript продлевает lease, только если он всё ещё принадлежит токену.
+var renewScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript удаляет lease, только если он всё ещё принадлежит токену.
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// RedisLock — lease-блокировка в Redis: ключ с уникальным токеном владельца и TTL.
+// Захват — SET NX PX, продление и освобождение — Lua-скрипты с проверкой токена,
+// чтобы реплика не продлила и не удалила чужой lease.
+type RedisLock struct {
+	client *redis.Client
+	key    string
+	ttl    time.Duration
+	token  string
+}
+
+// Lock возвращает RedisLock на ключе key с TTL ttl, использующий соединение кэша.
+// Каждый вызов создаёт нового владельца с собственным токеном.
+func (r *RedisCache) Lock(key string, ttl time.Duration) *RedisLock {
+	return &RedisLock{client: r.client, key: key, ttl: ttl, token: newInstanceID()}
+}
+
+// ID возвращает токен владельца.
+func (l *RedisLock) ID() string { return l.token }
+
+// Acquire пытается захватить lease. false — lease держит другой владелец.
+func (l *RedisLock) Acquire(ctx context.Context) (bool, error) {
+	return l.client.SetNX(ctx, l.key, l.token, l.ttl).Result()
+}
+
+// Renew продлевает lease на TTL. false — lease истёк или перешёл к другому владельцу.
+func (l *RedisLock) Renew(ctx context.Context) (bool, error) {
+	n, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Release освобождает lease, если он принадлежит этому владельцу.
+func (l *RedisLock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
+
+// Holder возвращает токен текущего владельца lease или "", если lease свободен.
+func (l *RedisLock) Holder(ctx context.Context) (string, error) {
+	v, err := l.client.Get(ctx, l.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return v, err
+}
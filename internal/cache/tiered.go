@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+)
+
+// Уровни TieredCache в метриках.
+const (
+	TierL1 = "l1"
+	TierL2 = "l2"
+)
+
+// Invalidator рассылает и принимает сообщения об изменённых ключах между репликами.
+// Свои собственные сообщения Subscribe не доставляет.
+type Invalidator interface {
+	Publish(ctx context.Context, key string) error
+	// Subscribe вызывает ready, когда подписка установлена, затем fn для каждого ключа,
+	// изменённого другой репликой, пока ctx не отменён или соединение не потеряно.
+	Subscribe(ctx context.Context, ready func(), fn func(key string)) error
+}
+
+// TieredOption настраивает TieredCache.
+type TieredOption func(*TieredCache)
+
+// WithInvalidation включает рассылку изменённых ключей через inv: реплики
+// удаляют такие ключи из своего L1 (см. TieredCache.Listen).
+func WithInvalidation(inv Invalidator) TieredOption {
+	return func(c *TieredCache) { c.invalidator = inv }
+}
+
+// WithResubscribeBackoff задаёт паузы между попытками восстановить подписку
+// на инвалидацию: от first, с удвоением до limit. По умолчанию 1s..30s.
+// Неположительные значения игнорируются.
+func WithResubscribeBackoff(first, limit time.Duration) TieredOption {
+	return func(c *TieredCache) {
+		if first > 0 && limit >= first {
+			c.backoffMin, c.backoffMax = first, limit
+		}
+	}
+}
+
+// TieredCache — двухуровневый кэш: in-process L1 с коротким TTL перед общим L2 (Redis).
+// Get сначала смотрит в L1, затем в L2 и при попадании в L2 заполняет L1.
+// Set пишет в оба уровня.
+type TieredCache struct {
+	l1          *MemoryCache
+	l2          Cache
+	metrics     observability.Metrics
+	invalidator Invalidator
+	backoffMin  time.Duration
+	backoffMax  time.Duration
+}
+
+// NewTieredCache собирает кэш из l1 и l2. metrics может быть nil — тогда будет использован noop.
+func NewTieredCache(l1 *MemoryCache, l2 Cache, m observability.Metrics, opts ...TieredOption) *TieredCache {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	c := &TieredCache{l1: l1, l2: l2, metrics: m, backoffMin: time.Second, backoffMax: 30 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if v, err := c.l1.Get(ctx, key); err == nil {
+		c.metrics.CacheTierLookup(TierL1, true)
+		return v, nil
+	}
+	c.metrics.CacheTierLookup(TierL1, false)
+
+	v, err := c.l2.Get(ctx, key)
+	if err != nil {
+		// ошибки L2, кроме промаха, тоже считаются промахом уровня
+		c.metrics.CacheTierLookup(TierL2, false)
+		return "", err
+	}
+	c.metrics.CacheTierLookup(TierL2, true)
+	_ = c.l1.Set(ctx, key, []byte(v))
+	return v, nil
+}
+
+// MGet берёт из L1 всё, что там есть, а остальные ключи запрашивает у L2 одним вызовом
+// и заполняет ими L1. При ошибке L2 возвращаются найденные в L1 значения вместе с ошибкой.
+func (c *TieredCache) MGet(ctx context.Context, keys []string) (map[string]string, error) {
+	out, _ := c.l1.MGet(ctx, keys)
+	var missing []string
+	for _, key := range keys {
+		if _, ok := out[key]; ok {
+			c.metrics.CacheTierLookup(TierL1, true)
+			continue
+		}
+		c.metrics.CacheTierLookup(TierL1, false)
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	found, err := c.l2.MGet(ctx, missing)
+	for _, key := range missing {
+		v, ok := found[key]
+		c.metrics.CacheTierLookup(TierL2, ok)
+		if !ok {
+			continue
+		}
+		out[key] = v
+		_ = c.l1.Set(ctx, key, []byte(v))
+	}
+	return out, err
+}
+
+// Set пишет значение в L2, затем в L1 и, если включена инвалидация, оповещает
+// остальные реплики. L1 обновляется даже при ошибке L2 — ошибка возвращается.
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte) error {
+	err := c.l2.Set(ctx, key, value)
+	_ = c.l1.Set(ctx, key, value)
+	c.publish(ctx, err, key)
+	return err
+}
+
+// SetWithTTL — как Set, но с собственным TTL. В L1 запись живёт не дольше TTL самого L1.
+func (c *TieredCache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return ErrInvalidTTL
+	}
+	err := c.l2.SetWithTTL(ctx, key, value, ttl)
+	_ = c.l1.SetWithTTL(ctx, key, value, c.l1TTL(ttl))
+	c.publish(ctx, err, key)
+	return err
+}
+
+// MSet — batch-вариант SetWithTTL: в L2 значения пишутся одним вызовом.
+func (c *TieredCache) MSet(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return ErrInvalidTTL
+	}
+	err := c.l2.MSet(ctx, values, ttl)
+	_ = c.l1.MSet(ctx, values, c.l1TTL(ttl))
+	for key := range values {
+		c.publish(ctx, err, key)
+	}
+	return err
+}
+
+// l1TTL ограничивает ttl записи TTL самого L1.
+func (c *TieredCache) l1TTL(ttl time.Duration) time.Duration {
+	if ttl > 0 && (c.l1.ttl <= 0 || ttl < c.l1.ttl) {
+		return ttl
+	}
+	return c.l1.ttl
+}
+
+// Delete удаляет ключ из обоих уровней и из L1 остальных реплик.
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	err := c.l2.Delete(ctx, key)
+	_ = c.l1.Delete(ctx, key)
+	c.publish(ctx, err, key)
+	return err
+}
+
+// publish оповещает остальные реплики об изменении key, если запись в L2 удалась.
+func (c *TieredCache) publish(ctx context.Context, l2Err error, key string) {
+	if l2Err == nil && c.invalidator != nil {
+		_ = c.invalidator.Publish(ctx, key)
+	}
+}
+
+// Listen удаляет из L1 ключи, изменённые другими репликами, пока ctx не отменён.
+// Если подписку не удалось установить или она оборвалась, Listen повторяет её
+// с экспоненциальной паузой (см. WithResubscribeBackoff); после восстановления
+// L1 очищается, так как сообщения за время разрыва потеряны.
+// Состояние подписки публикуется в метрике. Без WithInvalidation сразу возвращает nil.
+func (c *TieredCache) Listen(ctx context.Context) error {
+	if c.invalidator == nil {
+		return nil
+	}
+	wait := c.backoffMin
+	for attempt := 0; ; attempt++ {
+		subscribed := false
+		_ = c.invalidator.Subscribe(ctx, func() {
+			subscribed = true
+			if attempt > 0 {
+				c.l1.Clear()
+			}
+			c.metrics.SetInvalidationSubscribed(true)
+		}, func(key string) {
+			_ = c.l1.Delete(ctx, key)
+		})
+		c.metrics.SetInvalidationSubscribed(false)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if subscribed {
+			// подписка работала и оборвалась: пробуем снова без накопленной паузы
+			wait = c.backoffMin
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		wait = min(wait*2, c.backoffMax)
+	}
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// AggregateName — имя агрегирующего провайдера (Aggregator) в правилах маршрутизации.
+const AggregateName = "aggregate"
+
+// AggregateMethod — способ свести согласованные цены к одной.
+type AggregateMethod string
+
+const (
+	AggregateMedian AggregateMethod = "median" // медиана цен
+	AggregateVWAP   AggregateMethod = "vwap"   // средняя, взвешенная по объёму торгов (Quote.Volume)
+)
+
+// AggregateConfig задаёт правила агрегации.
+type AggregateConfig struct {
+	MinSources   int             // кворум: сколько цен должно остаться после отбраковки выбросов
+	MaxDeviation float64         // допустимое отклонение от медианы, доля (0.02 — 2%); 0 — без отбраковки
+	Method       AggregateMethod // пусто — AggregateMedian
+}
+
+func DefaultAggregateConfig() AggregateConfig {
+	return AggregateConfig{
+		MinSources:   2,
+		MaxDeviation: 0.02,
+		Method:       AggregateMedian,
+	}
+}
+
+// Aggregator — price.PriceClient, который запрашивает цену пары у всех провайдеров
+// сразу, отбрасывает цены, отклоняющиеся от медианы больше чем на MaxDeviation,
+// и отдаёт медиану или VWAP оставшихся. Если осталось меньше MinSources цен,
+// возвращается *price.QuorumError. Если пару не знает ни один провайдер — ErrUnknownID/ErrUnknownVS.
+//
+// Quote.Sources — число учтённых цен, Quote.Source — их провайдеры через запятую,
+// Quote.Volume — суммарный объём, FetchedAt — самая старая из учтённых цен.
+type Aggregator struct {
+	names     []string // в алфавитном порядке: детерминированный Source
+	providers map[string]price.PriceClient
+	cfg       atomic.Pointer[AggregateConfig]
+	metrics   observability.Metrics
+}
+
+// NewAggregator создаёт Aggregator над providers (ключ — имя провайдера в Source и метриках).
+// metrics может быть nil — тогда будет использован noop.
+func NewAggregator(providers map[string]price.PriceClient, cfg AggregateConfig, m observability.Metrics) *Aggregator {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	a := &Aggregator{names: names, providers: providers, metrics: m}
+	a.SetConfig(cfg)
+	return a
+}
+
+// SetConfig меняет правила агрегации на лету.
+func (a *Aggregator) SetConfig(cfg AggregateConfig) {
+	if cfg.MinSources <= 0 {
+		cfg.MinSources = 1
+	}
+	if cfg.Method == "" {
+		cfg.Method = AggregateMedian
+	}
+	a.cfg.Store(&cfg)
+}
+
+func (a *Aggregator) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := a.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// sample — цена пары от одного провайдера.
+type sample struct {
+	provider string
+	quote    price.Quote
+}
+
+func (a *Aggregator) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	samples := make([]sample, len(a.names))
+	errs := make([]error, len(a.names))
+	var wg sync.WaitGroup
+	for i, name := range a.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q, err := price.FetchQuote(ctx, a.providers[name], id, vs)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", name, err)
+				return
+			}
+			samples[i] = sample{provider: name, quote: q}
+		}()
+	}
+	wg.Wait()
+
+	var ok []sample
+	var failed []error
+	for i := range a.names {
+		if errs[i] != nil {
+			failed = append(failed, errs[i])
+		} else {
+			ok = append(ok, samples[i])
+		}
+	}
+	return a.combine(price.Pair{ID: id, VS: vs}, ok, failed)
+}
+
+// GetQuotes — GetPairs для пар ids × vs.
+func (a *Aggregator) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	return a.GetPairs(ctx, price.CrossPairs(ids, vs))
+}
+
+// GetPairs запрашивает pairs у всех провайдеров через price.FetchPairs и агрегирует
+// каждую пару отдельно. Пары, которых не знает ни один провайдер, в результат
+// не попадают; ошибки пар без кворума возвращаются как price.PairErrors.
+func (a *Aggregator) GetPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, error) {
+	results := make([]price.Quotes, len(a.names))
+	errs := make([]error, len(a.names))
+	var wg sync.WaitGroup
+	for i, name := range a.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = price.FetchPairs(ctx, a.providers[name], pairs)
+		}()
+	}
+	wg.Wait()
+
+	out := make(price.Quotes)
+	pairErrs := make(price.PairErrors)
+	for _, p := range pairs {
+		var ok []sample
+		var failed []error
+		for i, name := range a.names {
+			if q, found := results[i][p]; found {
+				ok = append(ok, sample{provider: name, quote: q})
+			} else if err := price.ErrorFor(errs[i], p); err != nil {
+				failed = append(failed, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+		if len(ok) == 0 && len(failed) == 0 {
+			continue // пару не знает никто
+		}
+		q, err := a.combine(p, ok, failed)
+		if err != nil {
+			pairErrs[p] = err
+			continue
+		}
+		out[p] = q
+	}
+	return out, pairErrs.Err()
+}
+
+// combine сводит цены пары p в одну котировку; failed — ошибки провайдеров без цены.
+func (a *Aggregator) combine(p price.Pair, samples []sample, failed []error) (price.Quote, error) {
+	cfg := a.cfg.Load()
+	if len(samples) == 0 && allUnsupported(failed) {
+		return price.Quote{}, errors.Join(failed...)
+	}
+
+	prices := make([]float64, len(samples))
+	for i, s := range samples {
+		prices[i] = s.quote.Price
+	}
+	mid := median(prices)
+	if len(samples) > 1 && mid > 0 {
+		a.metrics.ObserveAggregateSpread((slices.Max(prices) - slices.Min(prices)) / mid)
+	}
+
+	var inliers []sample
+	for _, s := range samples {
+		if cfg.MaxDeviation > 0 && (mid <= 0 || math.Abs(s.quote.Price-mid)/mid > cfg.MaxDeviation) {
+			a.metrics.AggregateOutlier(s.provider)
+			continue
+		}
+		inliers = append(inliers, s)
+	}
+	if len(inliers) < cfg.MinSources {
+		a.metrics.AggregateNoQuorum()
+		var err error
+		if len(failed) > 0 {
+			err = errors.Join(failed...)
+		}
+		return price.Quote{}, &price.QuorumError{Pair: p, Got: len(inliers), Need: cfg.MinSources, Err: err}
+	}
+
+	out := price.Quote{ID: p.ID, VS: p.VS, Sources: len(inliers)}
+	providers := make([]string, len(inliers))
+	prices = prices[:0]
+	var weighted float64
+	for i, s := range inliers {
+		q := s.quote
+		providers[i] = s.provider
+		prices = append(prices, q.Price)
+		out.Volume += q.Volume
+		weighted += q.Price * q.Volume
+		if out.FetchedAt.IsZero() || (!q.FetchedAt.IsZero() && q.FetchedAt.Before(out.FetchedAt)) {
+			out.FetchedAt = q.FetchedAt
+		}
+		if out.UpstreamUpdatedAt.IsZero() || (!q.UpstreamUpdatedAt.IsZero() && q.UpstreamUpdatedAt.Before(out.UpstreamUpdatedAt)) {
+			out.UpstreamUpdatedAt = q.UpstreamUpdatedAt
+		}
+	}
+	out.Source = strings.Join(providers, ",")
+	out.Price = median(prices)
+	// цены без объёма в VWAP не входят; если объёма нет ни у кого — медиана
+	if cfg.Method == AggregateVWAP && out.Volume > 0 {
+		out.Price = weighted / out.Volume
+	}
+	return out, nil
+}
+
+// allUnsupported сообщает, что все провайдеры ответили «не знаю пару».
+func allUnsupported(errs []error) bool {
+	for _, err := range errs {
+		if !unsupported(err) {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
+
+// median возвращает медиану xs (0 для пустого среза); xs не меняется.
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := slices.Clone(xs)
+	slices.Sort(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// BinanceName — значение Quote.Source для цен, полученных от Binance.
+const BinanceName = "binance"
+
+// binanceInvalidSymbol — код ошибки Binance для несуществующего рынка.
+const binanceInvalidSymbol = -1121
+
+// BinanceSymbols — тикеры Binance, отличающиеся от реестра asset: долларовых
+// рынков на Binance нет, цена в usd берётся из рынков к USDT.
+func BinanceSymbols() SymbolMap {
+	return SymbolMap{"usd": "USDT"}
+}
+
+// BinanceClient получает цены из публичного API Binance (/api/v3/ticker/24hr):
+// последнюю цену сделки и объём торгов за 24 часа.
+// Рынок пары — тикеры подряд: bitcoin/usd → BTCUSDT.
+type BinanceClient struct {
+	exchange
+}
+
+// NewBinanceClient создаёт клиент без повторов; см. SetRetryPolicy.
+// timeout ограничивает каждую попытку отдельно.
+func NewBinanceClient(timeout time.Duration) *BinanceClient {
+	return &BinanceClient{exchange: newExchange(BinanceName, "https://api.binance.com", BinanceSymbols(), timeout)}
+}
+
+// GetPrice возвращает последнюю цену сделки на рынке id/vs.
+func (c *BinanceClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// GetQuote — то же, что GetPrice, но с метаданными.
+// Ошибки типизированы: рынка нет — price.ErrUnknownVS, лимит — price.ErrRateLimited и т.д.
+func (c *BinanceClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	base, quote, err := c.market(id, vs)
+	if err != nil {
+		return price.Quote{}, err
+	}
+	symbol := base + quote
+	u := c.baseURL + "/api/v3/ticker/24hr?" + url.Values{"symbol": {symbol}}.Encode()
+
+	var body struct {
+		Symbol    string `json:"symbol"`
+		LastPrice string `json:"lastPrice"`
+		Volume    string `json:"volume"` // в базовом активе
+		Code      int    `json:"code"`
+		Msg       string `json:"msg"`
+	}
+	err = c.get(ctx, u, func(resp *http.Response) error {
+		body.Code, body.Msg = 0, ""
+		if resp.StatusCode == http.StatusBadRequest {
+			// 400 с кодом -1121 — такого рынка нет; прочие 400 — неожиданный статус
+			if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Code == binanceInvalidSymbol {
+				return c.noMarket(id, vs, symbol)
+			}
+		}
+		if resp.StatusCode != http.StatusOK {
+			return statusError(resp)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return fmt.Errorf("%w: decode json: %w", price.ErrBadPayload, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return price.Quote{}, err
+	}
+
+	p, err := strconv.ParseFloat(body.LastPrice, 64)
+	if err != nil {
+		return price.Quote{}, fmt.Errorf("%w: price %q: %w", price.ErrBadPayload, body.LastPrice, err)
+	}
+	q := c.quote(id, vs, p)
+	q.Volume, _ = strconv.ParseFloat(body.Volume, 64) // объём необязателен
+	return q, nil
+}
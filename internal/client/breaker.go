@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// BreakerState — состояние circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // запросы идут в backend
+	BreakerOpen                         // запросы отклоняются с price.ErrCircuitOpen
+	BreakerHalfOpen                     // пропускаются пробные запросы
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig задаёт пороги circuit breaker.
+type BreakerConfig struct {
+	FailureThreshold int           // ошибок подряд, после которых цепь размыкается
+	CoolDown         time.Duration // сколько цепь остаётся разомкнутой до пробных запросов
+	HalfOpenMaxCalls int           // одновременных пробных запросов в half-open
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		CoolDown:         30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// CircuitBreaker — декоратор price.PriceClient: после серии ошибок backend
+// перестаёт вызываться и запросы сразу получают price.ErrCircuitOpen.
+// Ошибками backend не считаются ErrUnknownID/ErrUnknownVS и отмена контекста вызывающим;
+// дедлайн вызывающего, истёкший во время вызова backend, считается ошибкой (backend завис).
+type CircuitBreaker struct {
+	name    string
+	backend price.PriceClient
+	cfg     BreakerConfig
+	metrics observability.Metrics
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int // пробных запросов в полёте (half-open)
+}
+
+// NewCircuitBreaker оборачивает backend. name используется в метриках.
+// metrics может быть nil — тогда будет использован noop.
+func NewCircuitBreaker(name string, backend price.PriceClient, cfg BreakerConfig, m observability.Metrics) *CircuitBreaker {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = 1
+	}
+	b := &CircuitBreaker{name: name, backend: backend, cfg: cfg, metrics: m}
+	m.SetBreakerState(name, int(BreakerClosed))
+	return b
+}
+
+// State возвращает текущее состояние (open с истёкшим cool-down отображается как half-open).
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.CoolDown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := b.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+func (b *CircuitBreaker) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	var q price.Quote
+	err := b.call(ctx, func() error {
+		var err error
+		q, err = price.FetchQuote(ctx, b.backend, id, vs)
+		return err
+	})
+	return q, err
+}
+
+func (b *CircuitBreaker) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	var quotes price.Quotes
+	err := b.call(ctx, func() error {
+		var err error
+		quotes, err = price.FetchQuotes(ctx, b.backend, ids, vs)
+		return err
+	})
+	return quotes, err
+}
+
+func (b *CircuitBreaker) GetPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, error) {
+	var quotes price.Quotes
+	err := b.call(ctx, func() error {
+		var err error
+		quotes, err = price.FetchPairs(ctx, b.backend, pairs)
+		return err
+	})
+	return quotes, err
+}
+
+// call выполняет fn, если цепь это позволяет, и учитывает результат.
+func (b *CircuitBreaker) call(ctx context.Context, fn func() error) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+	expiredBefore := ctx.Err() != nil
+	err = fn()
+	b.record(probe, breakerOutcome(ctx, err, expiredBefore))
+	return err
+}
+
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if time.Since(b.openedAt) < b.cfg.CoolDown {
+			return false, price.ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probes >= b.cfg.HalfOpenMaxCalls {
+			return false, price.ErrCircuitOpen
+		}
+		b.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// breakerResult — итог вызова backend для circuit breaker.
+type breakerResult int
+
+const (
+	breakerSuccess breakerResult = iota
+	breakerFailure
+	breakerIgnored // вызов ничего не говорит о backend: счётчики и состояние не меняются
+)
+
+func (b *CircuitBreaker) record(probe bool, r breakerResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probes--
+	}
+	switch r {
+	case breakerIgnored:
+		return
+	case breakerSuccess:
+		b.failures = 0
+		if b.state == BreakerHalfOpen {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState вызывается под b.mu.
+func (b *CircuitBreaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	if s != BreakerOpen {
+		b.failures = 0
+	}
+	b.metrics.SetBreakerState(b.name, int(s))
+}
+
+// breakerOutcome классифицирует результат вызова backend; expiredBefore — контекст
+// вызывающего был завершён ещё до вызова.
+func breakerOutcome(ctx context.Context, err error, expiredBefore bool) breakerResult {
+	switch {
+	case err == nil:
+		return breakerSuccess
+	case unsupported(err):
+		return breakerSuccess
+	case ctx.Err() == nil:
+		return breakerFailure
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) && !expiredBefore:
+		// backend не ответил, пока шёл вызов: для цепочки с коротким дедлайном
+		// вызывающего это единственный признак зависшего backend
+		return breakerFailure
+	default:
+		// вызывающий отменил запрос: о backend это ничего не говорит
+		return breakerIgnored
+	}
+}
@@ -3,64 +3,656 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"time"
 
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
 	"github.com/boxdancer/go-currency-tracker/internal/cache"
 	"github.com/boxdancer/go-currency-tracker/internal/observability"
 	"github.com/boxdancer/go-currency-tracker/internal/price"
 )
 
+// Freshness задаёт окна жизни закэшированной цены (отсчёт от момента получения у провайдера).
+//
+//	[0, FreshFor)                   — свежая, отдаётся из кэша;
+//	[FreshFor, FreshFor+StaleFor)   — устаревшая: отдаётся сразу, в фоне запрашивается новая;
+//	дальше                          — промах; если backend ответил ошибкой, а возраст < MaxStale,
+//	                                  отдаётся последняя известная цена с флагом Stale.
+type Freshness struct {
+	FreshFor time.Duration
+	StaleFor time.Duration
+	MaxStale time.Duration
+}
+
+// DefaultFreshness — поведение без stale-окон: цена живёт минуту.
+func DefaultFreshness() Freshness {
+	return Freshness{FreshFor: time.Minute}
+}
+
+// Retention — сколько запись должна храниться в кэше, чтобы все окна работали.
+// Используется как TTL кэша.
+func (f Freshness) Retention() time.Duration {
+	return max(f.FreshFor+f.StaleFor, f.MaxStale)
+}
+
+// CachedOption настраивает CachedPriceClient.
+type CachedOption func(*CachedPriceClient)
+
+// WithFreshness задаёт окна свежести кэша.
+func WithFreshness(f Freshness) CachedOption {
+	return func(c *CachedPriceClient) { c.SetFreshness(f) }
+}
+
+// WithClassFreshness задаёт окна свежести для пар отдельных классов активов
+// (см. PairClass), например более долгие для фиатных курсов. Пары остальных
+// классов и неизвестных активов используют WithFreshness.
+func WithClassFreshness(byClass map[asset.Class]Freshness) CachedOption {
+	return func(c *CachedPriceClient) { c.SetClassFreshness(byClass) }
+}
+
+// WithRefreshTimeout ограничивает фоновое обновление устаревших цен.
+func WithRefreshTimeout(d time.Duration) CachedOption {
+	return func(c *CachedPriceClient) { c.refreshTimeout = d }
+}
+
+// WithNegativeTTL включает negative caching: пары, которые backend не знает
+// (price.ErrUnknownID, price.ErrUnknownVS), запоминаются в кэше на d, и повторные
+// запросы получают ту же ошибку без обращения к backend. d <= 0 — выключено.
+// Временные ошибки backend так никогда не кэшируются.
+func WithNegativeTTL(d time.Duration) CachedOption {
+	return func(c *CachedPriceClient) { c.SetNegativeTTL(d) }
+}
+
 // CachedPriceClient оборачивает backend (любой price.PriceClient) и добавляет Redis-кэш.
+// Одновременные промахи по одному ключу price:id:vs объединяются в один запрос к backend.
 type CachedPriceClient struct {
-	backend price.PriceClient
-	cache   cache.Cache
-	metrics observability.Metrics
+	backend        price.PriceClient
+	cache          cache.Cache
+	metrics        observability.Metrics
+	flights        flightGroup
+	refreshTimeout time.Duration
+
+	// меняются на лету (см. SetFreshness, SetClassFreshness, SetNegativeTTL)
+	freshness      atomic.Pointer[Freshness]
+	classFreshness atomic.Pointer[map[asset.Class]Freshness]
+	negativeTTL    atomic.Int64 // time.Duration
 }
 
 // NewCachedPriceClient принимает backend, реализацию cache.Cache и observability.Metrics.
 // metrics может быть nil — тогда будет использован noop.
-func NewCachedPriceClient(backend price.PriceClient, c cache.Cache, m observability.Metrics) *CachedPriceClient {
+func NewCachedPriceClient(backend price.PriceClient, c cache.Cache, m observability.Metrics, opts ...CachedOption) *CachedPriceClient {
 	if m == nil {
 		m = observability.NewNoopMetrics()
 	}
-	return &CachedPriceClient{
-		backend: backend,
-		cache:   c,
-		metrics: m,
+	cc := &CachedPriceClient{
+		backend:        backend,
+		cache:          c,
+		metrics:        m,
+		refreshTimeout: 10 * time.Second,
+	}
+	cc.SetFreshness(DefaultFreshness())
+	cc.SetClassFreshness(nil)
+	for _, opt := range opts {
+		opt(cc)
+	}
+	return cc
+}
+
+// SetFreshness меняет окна свежести на лету. Новые записи сохраняются в кэш
+// с TTL f.Retention(), уже сохранённые оцениваются по новым окнам.
+func (c *CachedPriceClient) SetFreshness(f Freshness) {
+	c.freshness.Store(&f)
+}
+
+// SetClassFreshness меняет окна свежести классов на лету (см. WithClassFreshness).
+func (c *CachedPriceClient) SetClassFreshness(byClass map[asset.Class]Freshness) {
+	byClass = maps.Clone(byClass)
+	c.classFreshness.Store(&byClass)
+}
+
+// SetNegativeTTL меняет срок negative caching на лету (см. WithNegativeTTL).
+func (c *CachedPriceClient) SetNegativeTTL(d time.Duration) {
+	c.negativeTTL.Store(int64(d))
+}
+
+// freshFor возвращает окна свежести пары p с учётом её класса.
+func (c *CachedPriceClient) freshFor(p price.Pair) Freshness {
+	if class, ok := PairClass(p); ok {
+		if f, ok := (*c.classFreshness.Load())[class]; ok {
+			return f
+		}
+	}
+	return *c.freshness.Load()
+}
+
+// volatility упорядочивает классы активов: чем больше, тем быстрее меняется цена.
+var volatility = map[asset.Class]int{asset.Fiat: 0, asset.Stablecoin: 1, asset.Crypto: 2}
+
+// PairClass возвращает класс пары — более волатильный из классов id и vs:
+// bitcoin/usd — crypto, tether/usd — stablecoin, usd/rub — fiat.
+// ok=false, если какой-то из активов неизвестен.
+func PairClass(p price.Pair) (asset.Class, bool) {
+	id, okID := asset.Lookup(p.ID)
+	vs, okVS := asset.Lookup(p.VS)
+	if !okID || !okVS {
+		return "", false
+	}
+	if volatility[vs.Class] > volatility[id.Class] {
+		return vs.Class, true
 	}
+	return id.Class, true
+}
+
+func (c *CachedPriceClient) negTTL() time.Duration { return time.Duration(c.negativeTTL.Load()) }
+
+// cacheEntryVersion — версия формата cacheEntry. Увеличивается при несовместимых
+// изменениях; записи более новой версии (после отката) считаются промахом.
+const cacheEntryVersion = 2
+
+// negativeEntryVersion — версия записей-маркеров неизвестной пары. Она выше
+// cacheEntryVersion, чтобы сборки без negative caching не приняли маркер за цену 0.
+const negativeEntryVersion = 3
+
+// Значения cacheEntry.Negative.
+const (
+	negativeUnknownID = "unknown_id"
+	negativeUnknownVS = "unknown_vs"
+)
+
+// cacheEntry — конверт, который хранится в кэше под price:id:vs.
+// Версия 1 — голое число (только цена), читается до истечения TTL старых записей.
+type cacheEntry struct {
+	V                 int       `json:"v"`
+	Price             float64   `json:"price"`
+	FetchedAt         time.Time `json:"fetched_at"`
+	Source            string    `json:"source,omitempty"`
+	Sources           int       `json:"sources,omitempty"`
+	UpstreamUpdatedAt time.Time `json:"upstream_updated_at,omitzero"`
+	Negative          string    `json:"negative,omitempty"` // маркер неизвестной пары (v3): unknown_id или unknown_vs
 }
 
+// entryState — состояние записи кэша относительно Freshness.
+type entryState int
+
+const (
+	entryMissing entryState = iota // записи нет или она нечитаема
+	entryFresh
+	entryStale    // отдаём и обновляем в фоне
+	entryExpired  // только как запасной вариант при ошибке backend
+	entryNegative // пара неизвестна backend (negative caching)
+)
+
 func (c *CachedPriceClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
-	key := fmt.Sprintf("price:%s:%s", id, vs)
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// GetQuote возвращает цену с метаданными. При попадании в кэш Cached = true, а Source,
+// FetchedAt и UpstreamUpdatedAt берутся из записи; устаревшие цены помечаются Stale.
+func (c *CachedPriceClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	p := price.Pair{ID: id, VS: vs}
 
 	// Попытка взять из кэша (best-effort)
-	if c.cache != nil {
-		if val, err := c.cache.Get(ctx, key); err == nil {
-			var cached float64
-			if unmarshalErr := json.Unmarshal([]byte(val), &cached); unmarshalErr == nil {
-				c.metrics.CacheHit()
-				return cached, nil
+	e, state := c.lookup(ctx, p)
+	switch state {
+	case entryFresh:
+		return e.quote(id, vs, false), nil
+	case entryStale:
+		c.metrics.StaleServed("revalidate")
+		c.refreshInBackground([]price.Pair{{ID: id, VS: vs}})
+		return e.quote(id, vs, true), nil
+	case entryNegative:
+		return price.Quote{}, e.negativeError(id, vs)
+	}
+
+	// В кэше нет — идём в backend (или ждём того, кто уже пошёл)
+	q, err := c.fetchOne(ctx, p)
+	if err != nil && state == entryExpired && c.usableOnError(p, e, err) {
+		c.metrics.StaleServed("error")
+		return e.quote(id, vs, true), nil
+	}
+	return q, err
+}
+
+// fetchOne запрашивает пару у backend, объединяя одновременные запросы по ней.
+func (c *CachedPriceClient) fetchOne(ctx context.Context, p price.Pair) (price.Quote, error) {
+	key := cacheKey(p.ID, p.VS)
+	for {
+		call, leader := c.flights.join(key)
+		if !leader {
+			c.metrics.BackendCoalesced()
+			q, err := call.wait(ctx)
+			if call.leaderCancelled(ctx) {
+				continue
 			}
-			// если unmarshal не удался — продолжаем к backend
+			return q, err
+		}
+
+		start := time.Now()
+		q, err := price.FetchQuote(ctx, c.backend, p.ID, p.VS)
+		c.metrics.ObserveBackendCall(time.Since(start), err == nil)
+		w := c.newWrites()
+		if err == nil {
+			if q.FetchedAt.IsZero() {
+				q.FetchedAt = time.Now()
+			}
+			w.quote(p, q)
+		} else {
+			w.negative(p, err)
+		}
+		w.flush(ctx)
+		c.flights.finish(ctx, key, call, q, err)
+		return q, err
+	}
+}
+
+// GetPrices возвращает цены пар ids × vs (см. GetPairs). Как и положено
+// price.BatchClient, неизвестных пар просто нет в результате, без ошибки.
+func (c *CachedPriceClient) GetPrices(ctx context.Context, ids, vs []string) (map[string]map[string]float64, error) {
+	quotes, errs := c.getPairs(ctx, price.CrossPairs(ids, vs))
+	return quotes.Prices(), failures(errs)
+}
+
+// GetPairs возвращает котировки pairs. Все пары ищутся в кэше одним вызовом MGet,
+// промахи запрашиваются у backend через price.FetchPairs (одним вызовом, если
+// backend это умеет). Пары, которые уже запрашивает другой
+// вызывающий, не запрашиваются повторно. Кэшируется каждая пара отдельно.
+// Окна Freshness работают так же, как в GetQuote. Каждая пара без цены получает
+// свою ошибку в price.PairErrors: неизвестные пары (в ответе backend или из negative
+// cache) — price.ErrUnknownID или price.ErrUnknownVS, остальные — ошибку backend.
+// Ошибка остаётся и у пары, для которой вместо неё отдана устаревшая цена.
+func (c *CachedPriceClient) GetPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, error) {
+	quotes, errs := c.getPairs(ctx, pairs)
+	return quotes, errs.Err()
+}
+
+func (c *CachedPriceClient) getPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, price.PairErrors) {
+	results := make(price.Quotes, len(pairs))
+	errs := make(price.PairErrors)
+	var missing, stale []price.Pair
+	expired := make(map[price.Pair]cacheEntry)
+	entries := c.lookupMany(ctx, pairs)
+	for _, p := range pairs {
+		if _, done := results[p]; done {
+			continue
+		}
+		e, state := entries[p].entry, entries[p].state
+		switch state {
+		case entryFresh:
+			results[p] = e.quote(p.ID, p.VS, false)
+			continue
+		case entryStale:
+			c.metrics.StaleServed("revalidate")
+			results[p] = e.quote(p.ID, p.VS, true)
+			stale = append(stale, p)
+			continue
+		case entryNegative:
+			errs[p] = e.negativeError(p.ID, p.VS)
+			continue
+		case entryExpired:
+			expired[p] = e
+		}
+		if !slices.Contains(missing, p) {
+			missing = append(missing, p)
+		}
+	}
+	if len(stale) > 0 {
+		c.refreshInBackground(stale)
+	}
+
+	for len(missing) > 0 {
+		missing = c.fetchMissing(ctx, missing, results, errs)
+	}
+
+	// Для пар, которые так и не удалось получить, отдаём последнюю известную цену.
+	for p, e := range expired {
+		if err, failed := errs[p]; failed && c.usableOnError(p, e, err) {
+			c.metrics.StaleServed("error")
+			results[p] = e.quote(p.ID, p.VS, true)
+		}
+	}
+	return results, errs
+}
+
+// fetchMissing запрашивает пары, для которых вызывающий стал leader, одним batch-вызовом,
+// а для остальных дожидается чужих запросов. Цены кладёт в results, ошибки пар — в errs.
+// Возвращает пары, чей leader был отменён (их нужно запросить заново).
+func (c *CachedPriceClient) fetchMissing(ctx context.Context, missing []price.Pair, results price.Quotes, errs price.PairErrors) []price.Pair {
+	type waiter struct {
+		pair price.Pair
+		call *flightCall
+	}
+	var own []price.Pair
+	ownCalls := make(map[price.Pair]*flightCall)
+	var waiters []waiter
+	for _, p := range missing {
+		call, leader := c.flights.join(cacheKey(p.ID, p.VS))
+		if leader {
+			own = append(own, p)
+			ownCalls[p] = call
+			continue
+		}
+		c.metrics.BackendCoalesced()
+		waiters = append(waiters, waiter{pair: p, call: call})
+	}
+
+	if len(own) > 0 {
+		fetched, ownErrs := c.fetchAndStore(ctx, own, ownCalls)
+		maps.Copy(results, fetched)
+		maps.Copy(errs, ownErrs)
+	}
+
+	var retry []price.Pair
+	for _, w := range waiters {
+		q, err := w.call.wait(ctx)
+		switch {
+		case w.call.leaderCancelled(ctx):
+			retry = append(retry, w.pair)
+		case err == nil:
+			results[w.pair] = q
+		default:
+			errs[w.pair] = err
 		}
-		c.metrics.CacheMiss()
 	}
+	return retry
+}
 
-	// В кэше нет — идём в backend
+// fetchAndStore запрашивает pairs у backend, сохраняет полученные цены в кэш
+// и публикует результат каждой пары в её flightCall. Возвращает цены и ошибки
+// всех пар, для которых цены нет.
+func (c *CachedPriceClient) fetchAndStore(ctx context.Context, pairs []price.Pair, calls map[price.Pair]*flightCall) (price.Quotes, price.PairErrors) {
 	start := time.Now()
-	priceVal, err := c.backend.GetPrice(ctx, id, vs)
+	quotes, err := price.FetchPairs(ctx, c.backend, pairs)
 	c.metrics.ObserveBackendCall(time.Since(start), err == nil)
+
+	out := make(price.Quotes, len(pairs))
+	errs := make(price.PairErrors)
+	w := c.newWrites()
+	for _, p := range pairs {
+		q, ok := quotes[p]
+		if !ok {
+			pairErr := price.ErrorFor(err, p)
+			if pairErr == nil {
+				// backend ответил, но пары в ответе нет — это постоянная ошибка
+				pairErr = price.MissingPairError(p, quotes.HasID(p.ID))
+			}
+			// решается по ошибке самой пары: сбой соседних пар не мешает запомнить неизвестную
+			w.negative(p, pairErr)
+			errs[p] = pairErr
+			continue
+		}
+		if q.FetchedAt.IsZero() {
+			q.FetchedAt = time.Now()
+		}
+		out[p] = q
+		w.quote(p, q)
+	}
+	// весь batch пишется в кэш до того, как ожидающие получат результат
+	w.flush(ctx)
+	for _, p := range pairs {
+		c.flights.finish(ctx, cacheKey(p.ID, p.VS), calls[p], out[p], errs[p])
+	}
+	return out, errs
+}
+
+// Refresh запрашивает pairs у backend в обход кэша (одним batch-вызовом, если backend
+// это умеет) и сохраняет полученные цены. Пары, которые уже кто-то запрашивает,
+// пропускаются. Используется фоновым опросом (см. пакет poller).
+func (c *CachedPriceClient) Refresh(ctx context.Context, pairs []price.Pair) error {
+	own, calls := c.claim(pairs)
+	if len(own) == 0 {
+		return nil
+	}
+	_, errs := c.fetchAndStore(ctx, own, calls)
+	return failures(errs)
+}
+
+// failures возвращает ошибки errs без неизвестных пар (это ответ backend, а не сбой) или nil.
+func failures(errs price.PairErrors) error {
+	out := make(price.PairErrors)
+	for p, err := range errs {
+		if !unsupported(err) {
+			out[p] = err
+		}
+	}
+	return out.Err()
+}
+
+// refreshInBackground обновляет устаревшие пары вне запроса пользователя.
+// Пары, которые уже кто-то запрашивает, пропускаются.
+func (c *CachedPriceClient) refreshInBackground(pairs []price.Pair) {
+	own, calls := c.claim(pairs)
+	if len(own) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.refreshTimeout)
+		defer cancel()
+		_, _ = c.fetchAndStore(ctx, own, calls)
+	}()
+}
+
+// claim становится leader для тех pairs, которые ещё никто не запрашивает.
+// Для каждой возвращённой пары вызывающий обязан вызвать flights.finish.
+func (c *CachedPriceClient) claim(pairs []price.Pair) ([]price.Pair, map[price.Pair]*flightCall) {
+	var own []price.Pair
+	calls := make(map[price.Pair]*flightCall)
+	for _, p := range pairs {
+		call, leader := c.flights.join(cacheKey(p.ID, p.VS))
+		if !leader {
+			continue
+		}
+		own = append(own, p)
+		calls[p] = call
+	}
+	return own, calls
+}
+
+// usableOnError сообщает, можно ли отдать запись вместо ошибки backend.
+// Постоянные ошибки (неизвестная пара) устаревшей ценой не маскируются.
+func (c *CachedPriceClient) usableOnError(p price.Pair, e cacheEntry, err error) bool {
+	if errors.Is(err, price.ErrUnknownID) || errors.Is(err, price.ErrUnknownVS) {
+		return false
+	}
+	return !e.FetchedAt.IsZero() && time.Since(e.FetchedAt) < c.freshFor(p).MaxStale
+}
+
+// lookup достаёт запись из кэша, определяет её состояние и учитывает hit/miss в метриках.
+func (c *CachedPriceClient) lookup(ctx context.Context, p price.Pair) (cacheEntry, entryState) {
+	if c.cache == nil {
+		return cacheEntry{}, entryMissing
+	}
+	val, err := c.cache.Get(ctx, cacheKey(p.ID, p.VS))
+	return c.classify(p, val, err == nil)
+}
+
+// lookupResult — запись кэша вместе с её состоянием.
+type lookupResult struct {
+	entry cacheEntry
+	state entryState
+}
+
+// lookupMany — batch-вариант lookup: записи всех pairs одним вызовом MGet.
+// Ошибка MGet (как и в lookup) означает промах по ненайденным ключам.
+func (c *CachedPriceClient) lookupMany(ctx context.Context, pairs []price.Pair) map[price.Pair]lookupResult {
+	out := make(map[price.Pair]lookupResult, len(pairs))
+	if c.cache == nil {
+		return out
+	}
+	keys := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		keys = append(keys, cacheKey(p.ID, p.VS))
+	}
+	vals, _ := c.cache.MGet(ctx, keys)
+	for _, p := range pairs {
+		if _, seen := out[p]; seen {
+			continue
+		}
+		val, found := vals[cacheKey(p.ID, p.VS)]
+		e, state := c.classify(p, val, found)
+		out[p] = lookupResult{entry: e, state: state}
+	}
+	return out
+}
+
+// classify разбирает значение пары p из кэша (found=false — ключа нет) и учитывает hit/miss.
+func (c *CachedPriceClient) classify(p price.Pair, val string, found bool) (cacheEntry, entryState) {
+	if !found {
+		c.metrics.CacheMiss()
+		return cacheEntry{}, entryMissing
+	}
+	e, ok := decodeEntry(val)
+	if !ok {
+		// если unmarshal не удался — считаем промахом
+		c.metrics.CacheMiss()
+		return cacheEntry{}, entryMissing
+	}
+	if e.Negative != "" {
+		// маркер живёт не дольше negativeTTL, даже если кэш не соблюдает TTL записи
+		if ttl := c.negTTL(); ttl <= 0 || time.Since(e.FetchedAt) >= ttl {
+			c.metrics.CacheMiss()
+			return cacheEntry{}, entryMissing
+		}
+		c.metrics.NegativeCacheHit()
+		return e, entryNegative
+	}
+
+	state := c.state(p, e)
+	if state == entryExpired {
+		c.metrics.CacheMiss()
+	} else {
+		c.metrics.CacheHit()
+	}
+	return e, state
+}
+
+func (c *CachedPriceClient) state(p price.Pair, e cacheEntry) entryState {
+	if e.FetchedAt.IsZero() {
+		// старый формат без времени: возраст ограничен TTL кэша
+		return entryFresh
+	}
+	age := time.Since(e.FetchedAt)
+	f := c.freshFor(p)
+	switch {
+	case age < f.FreshFor:
+		return entryFresh
+	case age < f.FreshFor+f.StaleFor:
+		return entryStale
+	default:
+		return entryExpired
+	}
+}
+
+// cacheWrites копит записи для кэша, сгруппированные по TTL, чтобы записать их
+// одним MSet на каждый TTL (ошибки записи игнорируем).
+type cacheWrites struct {
+	c     *CachedPriceClient
+	byTTL map[time.Duration]map[string][]byte
+}
+
+func (c *CachedPriceClient) newWrites() *cacheWrites {
+	return &cacheWrites{c: c, byTTL: make(map[time.Duration]map[string][]byte)}
+}
+
+// quote добавляет котировку пары p с TTL Freshness.Retention() её класса.
+func (w *cacheWrites) quote(p price.Pair, q price.Quote) {
+	e := cacheEntry{
+		V:                 cacheEntryVersion,
+		Price:             q.Price,
+		FetchedAt:         q.FetchedAt,
+		Source:            q.Source,
+		Sources:           q.Sources,
+		UpstreamUpdatedAt: q.UpstreamUpdatedAt,
+	}
+	w.add(p, e, w.c.freshFor(p).Retention())
+}
+
+// negative запоминает пару как неизвестную, если err — постоянная ошибка
+// (price.ErrUnknownID, price.ErrUnknownVS) и negative caching включён.
+func (w *cacheWrites) negative(p price.Pair, err error) {
+	ttl := w.c.negTTL()
+	if ttl <= 0 {
+		return
+	}
+	e := cacheEntry{V: negativeEntryVersion, FetchedAt: time.Now()}
+	switch {
+	case errors.Is(err, price.ErrUnknownID):
+		e.Negative = negativeUnknownID
+	case errors.Is(err, price.ErrUnknownVS):
+		e.Negative = negativeUnknownVS
+	default:
+		return
+	}
+	w.add(p, e, ttl)
+}
+
+func (w *cacheWrites) add(p price.Pair, e cacheEntry, ttl time.Duration) {
+	data, err := json.Marshal(e)
 	if err != nil {
-		return 0, err
+		return
+	}
+	if w.byTTL[ttl] == nil {
+		w.byTTL[ttl] = make(map[string][]byte)
+	}
+	w.byTTL[ttl][cacheKey(p.ID, p.VS)] = data
+}
+
+// flush пишет накопленные записи в кэш.
+func (w *cacheWrites) flush(ctx context.Context) {
+	if w.c.cache == nil {
+		return
+	}
+	for ttl, values := range w.byTTL {
+		_ = w.c.cache.MSet(ctx, values, ttl)
 	}
+}
 
-	// Сохраняем в кэш (ошибки от Set игнорируем)
-	if c.cache != nil {
-		if data, marshalErr := json.Marshal(priceVal); marshalErr == nil {
-			_ = c.cache.Set(ctx, key, data)
+// decodeEntry разбирает запись кэша. Поддерживается старый формат — голое число,
+// а также конверт без поля v (промежуточный формат {price, fetched_at}).
+// Записи неизвестной (более новой) версии не читаются; версия 3 допустима только
+// для маркеров negative caching.
+func decodeEntry(val string) (cacheEntry, bool) {
+	var e cacheEntry
+	if err := json.Unmarshal([]byte(val), &e); err == nil {
+		if e.V > negativeEntryVersion || (e.V > cacheEntryVersion && e.Negative == "") {
+			return cacheEntry{}, false
 		}
+		return e, true
+	}
+	var legacy float64
+	if err := json.Unmarshal([]byte(val), &legacy); err == nil {
+		return cacheEntry{V: 1, Price: legacy}, true
+	}
+	return cacheEntry{}, false
+}
+
+// negativeError восстанавливает ошибку, из-за которой пара попала в negative cache.
+func (e cacheEntry) negativeError(id, vs string) error {
+	return price.MissingPairError(price.Pair{ID: id, VS: vs}, e.Negative == negativeUnknownVS)
+}
+
+func (e cacheEntry) quote(id, vs string, stale bool) price.Quote {
+	return price.Quote{
+		ID:                id,
+		VS:                vs,
+		Price:             e.Price,
+		Source:            e.Source,
+		Sources:           e.Sources,
+		FetchedAt:         e.FetchedAt,
+		UpstreamUpdatedAt: e.UpstreamUpdatedAt,
+		Cached:            true,
+		Stale:             stale,
 	}
+}
 
-	return priceVal, nil
+func cacheKey(id, vs string) string {
+	return fmt.Sprintf("price:%s:%s", id, vs)
 }
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// CoinbaseName — значение Quote.Source для цен, полученных от Coinbase.
+const CoinbaseName = "coinbase"
+
+// CoinbaseClient получает спотовые цены из публичного API Coinbase (/v2/prices/{market}/spot).
+// Рынок пары — тикеры через дефис: bitcoin/usd → BTC-USD. Тикеры Coinbase совпадают
+// с реестром asset, поэтому карта по умолчанию пуста.
+type CoinbaseClient struct {
+	exchange
+}
+
+// NewCoinbaseClient создаёт клиент без повторов; см. SetRetryPolicy.
+// timeout ограничивает каждую попытку отдельно.
+func NewCoinbaseClient(timeout time.Duration) *CoinbaseClient {
+	return &CoinbaseClient{exchange: newExchange(CoinbaseName, "https://api.coinbase.com", nil, timeout)}
+}
+
+// GetPrice возвращает спотовую цену id в vs.
+func (c *CoinbaseClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// GetQuote — то же, что GetPrice, но с метаданными.
+// Ошибки типизированы: рынка нет — price.ErrUnknownVS, лимит — price.ErrRateLimited и т.д.
+func (c *CoinbaseClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	base, quote, err := c.market(id, vs)
+	if err != nil {
+		return price.Quote{}, err
+	}
+	market := base + "-" + quote
+	u := c.baseURL + "/v2/prices/" + url.PathEscape(market) + "/spot"
+
+	var body struct {
+		Data struct {
+			Amount string `json:"amount"`
+		} `json:"data"`
+	}
+	err = c.get(ctx, u, func(resp *http.Response) error {
+		switch {
+		case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusBadRequest:
+			// Coinbase отвечает 404/400 "Invalid currency" на рынок, которого нет
+			return c.noMarket(id, vs, market)
+		case resp.StatusCode != http.StatusOK:
+			return statusError(resp)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return fmt.Errorf("%w: decode json: %w", price.ErrBadPayload, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return price.Quote{}, err
+	}
+
+	p, err := strconv.ParseFloat(body.Data.Amount, 64)
+	if err != nil {
+		return price.Quote{}, fmt.Errorf("%w: amount %q: %w", price.ErrBadPayload, body.Data.Amount, err)
+	}
+	return c.quote(id, vs, p), nil
+}
@@ -5,35 +5,106 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
 )
 
+// CoinGeckoName — значение Quote.Source для цен, полученных от CoinGecko.
+const CoinGeckoName = "coingecko"
+
 type CoinGeckoClient struct {
 	http    *http.Client
 	baseURL string
+	retry   retrier
 }
 
+// NewCoinGeckoClient создаёт клиент без повторов; см. SetRetryPolicy.
+// timeout ограничивает каждую попытку отдельно.
 func NewCoinGeckoClient(timeout time.Duration) *CoinGeckoClient {
 	return &CoinGeckoClient{
 		http: &http.Client{
 			Timeout: timeout,
 		},
 		baseURL: "https://api.coingecko.com",
+		retry:   retrier{provider: CoinGeckoName, metrics: observability.NewNoopMetrics()},
 	}
 }
 
 // GetPrice возвращает цену монеты id в фиате vs (например: id="bitcoin", vs="usd").
 func (c *CoinGeckoClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
-	url := fmt.Sprintf("%s/api/v3/simple/price?ids=%s&vs_currencies=%s", c.baseURL, id, vs)
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// GetQuote — то же, что GetPrice, но с метаданными (источник, время получения
+// и время обновления цены в CoinGecko).
+// Ошибки типизированы (price.ErrUnknownID, price.ErrRateLimited и т.д.).
+func (c *CoinGeckoClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	quotes, err := c.fetch(ctx, []string{id}, []string{vs})
+	if err != nil {
+		return price.Quote{}, err
+	}
+
+	p := price.Pair{ID: id, VS: vs}
+	q, ok := quotes[p]
+	if !ok {
+		return price.Quote{}, price.MissingPairError(p, quotes.HasID(id))
+	}
+	return q, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// GetPrices получает цены всех пар ids × vs одним запросом к /simple/price.
+// Пары, которых нет в ответе CoinGecko, в результате отсутствуют.
+func (c *CoinGeckoClient) GetPrices(ctx context.Context, ids, vs []string) (map[string]map[string]float64, error) {
+	quotes, err := c.fetch(ctx, ids, vs)
 	if err != nil {
-		return 0, fmt.Errorf("build request: %w", err)
+		return nil, err
+	}
+	return quotes.Prices(), nil
+}
+
+// GetQuotes — batch-вариант GetQuote: один запрос к /simple/price для всех пар ids × vs.
+func (c *CoinGeckoClient) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	return c.fetch(ctx, ids, vs)
+}
+
+// fetch выполняет запрос /simple/price для списков ids и vs с повторами по RetryPolicy.
+func (c *CoinGeckoClient) fetch(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	var quotes price.Quotes
+	err := c.retry.do(ctx, func() error {
+		var err error
+		quotes, err = c.fetchOnce(ctx, ids, vs)
+		return err
+	})
+	return quotes, err
+}
+
+// lastUpdatedKey — служебное поле ответа /simple/price при include_last_updated_at=true.
+const lastUpdatedKey = "last_updated_at"
+
+// fetchOnce выполняет одну попытку запроса /simple/price.
+func (c *CoinGeckoClient) fetchOnce(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	q := url.Values{}
+	q.Set("ids", strings.Join(ids, ","))
+	q.Set("vs_currencies", strings.Join(vs, ","))
+	q.Set("include_last_updated_at", "true")
+	u := c.baseURL + "/api/v3/simple/price?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
 	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("do request: %w", err)
+		return nil, transportError(ctx, err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -44,23 +115,36 @@ func (c *CoinGeckoClient) GetPrice(ctx context.Context, id, vs string) (float64,
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+		return nil, statusError(resp)
 	}
 
 	var data map[string]map[string]float64
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return 0, fmt.Errorf("decode json: %w", err)
+		return nil, fmt.Errorf("%w: decode json: %w", price.ErrBadPayload, err)
 	}
 
-	priceMap, ok := data[id]
-	if !ok {
-		return 0, fmt.Errorf("no id %q in response", id)
-	}
-	price, ok := priceMap[vs]
-	if !ok {
-		return 0, fmt.Errorf("no vs %q for id %q in response", vs, id)
+	fetchedAt := time.Now()
+	quotes := make(price.Quotes)
+	for id, byVS := range data {
+		var updatedAt time.Time
+		if ts, ok := byVS[lastUpdatedKey]; ok && ts > 0 {
+			updatedAt = time.Unix(int64(ts), 0)
+		}
+		for v, p := range byVS {
+			if v == lastUpdatedKey {
+				continue
+			}
+			quotes[price.Pair{ID: id, VS: v}] = price.Quote{
+				ID:                id,
+				VS:                v,
+				Price:             p,
+				Source:            CoinGeckoName,
+				FetchedAt:         fetchedAt,
+				UpstreamUpdatedAt: updatedAt,
+			}
+		}
 	}
-	return price, nil
+	return quotes, nil
 }
 
 // SetBaseURL allows tests (or advanced usage) to override the default API base URL.
@@ -79,3 +163,23 @@ func (c *CoinGeckoClient) SetHTTPClient(h *http.Client) {
 	}
 	c.http = h
 }
+
+// SetRetryPolicy включает повторы запросов (429, 5xx, сетевые ошибки) по политике p.
+func (c *CoinGeckoClient) SetRetryPolicy(p RetryPolicy) {
+	c.retry.policy = p
+}
+
+// SetRetryLimiter заставляет каждый повтор запроса расходовать токен l, чтобы повторы
+// не выходили за лимит провайдера. Первую попытку оплачивает RateLimitedClient,
+// оборачивающий клиент; если токена на повтор нет, возвращается ошибка последней попытки.
+func (c *CoinGeckoClient) SetRetryLimiter(l Limiter) {
+	c.retry.limiter = l
+}
+
+// SetMetrics задаёт метрики для учёта повторов. nil игнорируется.
+func (c *CoinGeckoClient) SetMetrics(m observability.Metrics) {
+	if m == nil {
+		return
+	}
+	c.retry.metrics = m
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// statusError переводит не-200 ответ провайдера в типизированную ошибку пакета price.
+func statusError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &price.RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
+	return &price.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+}
+
+// transportError оборачивает ошибку http.Client.Do. Отмена/дедлайн контекста
+// вызывающего не считаются недоступностью провайдера.
+func transportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	return fmt.Errorf("%w: do request: %w", price.ErrUpstreamUnavailable, err)
+}
+
+// parseRetryAfter разбирает заголовок Retry-After: число секунд или HTTP-дату.
+// Возвращает 0, если заголовок пуст или некорректен.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
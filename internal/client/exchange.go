@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// SymbolMap переводит идентификаторы сервиса (id CoinGecko для крипты, ISO-код
+// для фиата) в тикеры биржи: "bitcoin" → "BTC" (или "XBT" на Kraken).
+// Идентификаторы, которых нет в карте, берут тикер из реестра asset.
+// Пустое значение в карте означает, что биржа актив не поддерживает.
+type SymbolMap map[string]string
+
+// Symbol возвращает тикер биржи для id.
+func (m SymbolMap) Symbol(id string) (string, bool) {
+	if s, ok := m[id]; ok {
+		return s, s != ""
+	}
+	a, ok := asset.Lookup(id)
+	if !ok {
+		return "", false
+	}
+	return a.Symbol, true
+}
+
+// exchange — общая часть клиентов публичных ticker API бирж: HTTP, повторы и
+// перевод пар в тикеры. Клиенты встраивают её и получают SetBaseURL и остальные сеттеры.
+type exchange struct {
+	name    string
+	http    *http.Client
+	baseURL string
+	retry   retrier
+	symbols SymbolMap
+}
+
+func newExchange(name, baseURL string, symbols SymbolMap, timeout time.Duration) exchange {
+	return exchange{
+		name:    name,
+		http:    &http.Client{Timeout: timeout},
+		baseURL: baseURL,
+		retry:   retrier{provider: name, metrics: observability.NewNoopMetrics()},
+		symbols: symbols,
+	}
+}
+
+// market возвращает тикеры base и quote для пары id/vs.
+// Если биржа не знает актив, возвращается price.ErrUnknownID или price.ErrUnknownVS без запроса.
+func (e *exchange) market(id, vs string) (base, quote string, err error) {
+	base, ok := e.symbols.Symbol(id)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q has no %s symbol", price.ErrUnknownID, id, e.name)
+	}
+	quote, ok = e.symbols.Symbol(vs)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q has no %s symbol", price.ErrUnknownVS, vs, e.name)
+	}
+	return base, quote, nil
+}
+
+// get выполняет GET u с повторами по RetryPolicy; ответ (с любым статусом) разбирает handle.
+func (e *exchange) get(ctx context.Context, u string, handle func(*http.Response) error) error {
+	return e.retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		resp, err := e.http.Do(req)
+		if err != nil {
+			return transportError(ctx, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return handle(resp)
+	})
+}
+
+// quote собирает Quote пары id/vs, полученной от биржи сейчас.
+func (e *exchange) quote(id, vs string, p float64) price.Quote {
+	return price.Quote{ID: id, VS: vs, Price: p, Source: e.name, FetchedAt: time.Now()}
+}
+
+// noMarket — ошибка для пары, которой нет на бирже: актив известен, но не торгуется к vs.
+func (e *exchange) noMarket(id, vs, market string) error {
+	return fmt.Errorf("%w: no %s market %s for %s/%s", price.ErrUnknownVS, e.name, market, id, vs)
+}
+
+// SetBaseURL allows tests (or advanced usage) to override the default API base URL.
+// Pass an empty string to ignore.
+func (e *exchange) SetBaseURL(u string) {
+	if u == "" {
+		return
+	}
+	e.baseURL = u
+}
+
+// SetHTTPClient allows injecting a custom http.Client (optional - useful for tests).
+func (e *exchange) SetHTTPClient(h *http.Client) {
+	if h == nil {
+		return
+	}
+	e.http = h
+}
+
+// SetRetryPolicy включает повторы запросов (429, 5xx, сетевые ошибки) по политике p.
+func (e *exchange) SetRetryPolicy(p RetryPolicy) {
+	e.retry.policy = p
+}
+
+// SetMetrics задаёт метрики для учёта повторов. nil игнорируется.
+func (e *exchange) SetMetrics(m observability.Metrics) {
+	if m == nil {
+		return
+	}
+	e.retry.metrics = m
+}
+
+// SetSymbols заменяет карту тикеров биржи (см. SymbolMap). nil — только реестр asset.
+func (e *exchange) SetSymbols(m SymbolMap) {
+	e.symbols = m
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// FailoverName — имя провайдера-цепочки (Failover) в правилах маршрутизации.
+const FailoverName = "failover"
+
+// FailoverConfig задаёт оценку здоровья провайдеров в Failover.
+type FailoverConfig struct {
+	Decay       float64       // вес последнего результата в оценке здоровья, (0, 1]
+	DemoteBelow float64       // провайдер с оценкой ниже порога понижается в конец цепочки
+	DemoteFor   time.Duration // на сколько понижается провайдер
+}
+
+func DefaultFailoverConfig() FailoverConfig {
+	return FailoverConfig{
+		Decay:       0.5,
+		DemoteBelow: 0.3,
+		DemoteFor:   time.Minute,
+	}
+}
+
+// providerHealth — оценка здоровья провайдера: 1 — все запросы успешны, 0 — все неудачны.
+type providerHealth struct {
+	score        float64
+	demotedUntil time.Time // zero — провайдер не понижен
+}
+
+// Failover — price.PriceClient, который опрашивает провайдеров по приоритету и переходит
+// к следующему, если текущий ответил временной ошибкой (лимит запросов, 5xx, сеть,
+// разомкнутый circuit breaker) или не знает пару. Прочие ошибки возвращаются сразу.
+//
+// Для каждого провайдера ведётся оценка здоровья — экспоненциальное среднее успешных
+// запросов, где временная ошибка считается неудачей. Провайдер с оценкой ниже
+// DemoteBelow на DemoteFor уходит в конец цепочки и спрашивается, только если
+// остальные не ответили; затем возвращается на своё место с оценкой DemoteBelow,
+// так что первая же ошибка снова его понизит.
+// Quote.Source — ответивший провайдер.
+type Failover struct {
+	names     []string // в порядке приоритета
+	providers []price.PriceClient
+	cfg       atomic.Pointer[FailoverConfig]
+	metrics   observability.Metrics
+	now       func() time.Time
+
+	mu     sync.Mutex
+	health []providerHealth
+}
+
+// NewFailover создаёт Failover над провайдерами order (по убыванию приоритета);
+// все они должны быть в providers. metrics может быть nil — тогда будет использован noop.
+func NewFailover(order []string, providers map[string]price.PriceClient, cfg FailoverConfig, m observability.Metrics) (*Failover, error) {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	if len(order) == 0 {
+		return nil, errors.New("failover: no providers")
+	}
+	f := &Failover{names: order, metrics: m, now: time.Now, health: make([]providerHealth, len(order))}
+	for i, name := range order {
+		p, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("failover: unknown provider %q", name)
+		}
+		f.providers = append(f.providers, p)
+		f.health[i].score = 1
+		m.SetProviderHealth(name, 1)
+	}
+	f.SetConfig(cfg)
+	return f, nil
+}
+
+// SetConfig меняет параметры оценки здоровья на лету; уже пониженные провайдеры
+// остаются пониженными до прежнего срока.
+func (f *Failover) SetConfig(cfg FailoverConfig) {
+	if cfg.Decay <= 0 || cfg.Decay > 1 {
+		cfg.Decay = DefaultFailoverConfig().Decay
+	}
+	f.cfg.Store(&cfg)
+}
+
+// SetClock allows tests to control time (demotion periods).
+func (f *Failover) SetClock(now func() time.Time) {
+	if now == nil {
+		return
+	}
+	f.now = now
+}
+
+// Health возвращает оценку здоровья провайдера name и признак понижения.
+func (f *Failover) Health(name string) (score float64, demoted bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, n := range f.names {
+		if n == name {
+			h := f.health[i]
+			return h.score, !h.demotedUntil.IsZero() && f.now().Before(h.demotedUntil)
+		}
+	}
+	return 0, false
+}
+
+func (f *Failover) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := f.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+func (f *Failover) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	var failed, unknown error
+	for _, i := range f.order() {
+		name := f.names[i]
+		q, err := price.FetchQuote(ctx, f.providers[i], id, vs)
+		switch {
+		case err == nil:
+			f.record(i, true)
+			f.metrics.FailoverResult(name, "served")
+			return withSource(q, name), nil
+		case unsupported(err):
+			f.metrics.FailoverResult(name, "unknown")
+			if unknown == nil {
+				unknown = err
+			}
+		case retryable(err) && ctx.Err() == nil:
+			f.record(i, false)
+			f.metrics.FailoverResult(name, "failed")
+			failed = errors.Join(failed, fmt.Errorf("%s: %w", name, err))
+		default:
+			f.metrics.FailoverResult(name, "error")
+			return price.Quote{}, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	// временная ошибка важнее «не знаю пару»: её нельзя запоминать как неизвестную пару
+	if failed != nil {
+		return price.Quote{}, failed
+	}
+	return price.Quote{}, unknown
+}
+
+// GetQuotes — GetPairs для пар ids × vs.
+func (f *Failover) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	return f.GetPairs(ctx, price.CrossPairs(ids, vs))
+}
+
+// GetPairs запрашивает pairs у провайдеров по приоритету через price.FetchPairs:
+// следующему провайдеру достаются только пары, на которые предыдущие не ответили
+// или ответили временной ошибкой. Пары, так и оставшиеся без цены, возвращаются
+// с последней ошибкой каждой из них (price.PairErrors).
+func (f *Failover) GetPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, error) {
+	pending := slices.Clone(pairs)
+
+	out := make(price.Quotes)
+	errs := make(price.PairErrors)
+	for _, i := range f.order() {
+		if len(pending) == 0 || ctx.Err() != nil {
+			break
+		}
+		name := f.names[i]
+		quotes, err := price.FetchPairs(ctx, f.providers[i], pending)
+		var failed, hard bool
+		rest := pending[:0:0]
+		for _, p := range pending {
+			if q, ok := quotes[p]; ok {
+				out[p] = withSource(q, name)
+				delete(errs, p)
+				continue
+			}
+			pairErr := price.ErrorFor(err, p)
+			switch {
+			case pairErr == nil || unsupported(pairErr):
+				// временная ошибка другого провайдера важнее «не знаю пару»
+			case retryable(pairErr):
+				failed = true
+				errs[p] = fmt.Errorf("%s: %w", name, pairErr)
+			default:
+				hard = true
+				errs[p] = fmt.Errorf("%s: %w", name, pairErr)
+				continue // постоянная ошибка: другим провайдерам пару не отдаём
+			}
+			rest = append(rest, p)
+		}
+		pending = rest
+
+		switch {
+		case failed:
+			f.record(i, false)
+			f.metrics.FailoverResult(name, "failed")
+		case hard:
+			f.metrics.FailoverResult(name, "error")
+		default:
+			f.record(i, true)
+			if len(quotes) > 0 {
+				f.metrics.FailoverResult(name, "served")
+			}
+		}
+	}
+	return out, errs.Err()
+}
+
+// order возвращает индексы провайдеров: сначала здоровые по приоритету, затем пониженные.
+// Провайдеры с истёкшим сроком понижения возвращаются на место.
+func (f *Failover) order() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	active := make([]int, 0, len(f.names))
+	var demoted []int
+	for i := range f.health {
+		h := &f.health[i]
+		if !h.demotedUntil.IsZero() && !now.Before(h.demotedUntil) {
+			h.demotedUntil = time.Time{}
+			h.score = f.cfg.Load().DemoteBelow
+			f.metrics.SetProviderHealth(f.names[i], h.score)
+		}
+		if h.demotedUntil.IsZero() {
+			active = append(active, i)
+		} else {
+			demoted = append(demoted, i)
+		}
+	}
+	return append(active, demoted...)
+}
+
+// record учитывает результат запроса к провайдеру i в оценке его здоровья.
+func (f *Failover) record(i int, ok bool) {
+	cfg := f.cfg.Load()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := &f.health[i]
+	result := 0.0
+	if ok {
+		result = 1
+	}
+	h.score = h.score*(1-cfg.Decay) + result*cfg.Decay
+	f.metrics.SetProviderHealth(f.names[i], h.score)
+	if !ok && h.demotedUntil.IsZero() && h.score < cfg.DemoteBelow {
+		h.demotedUntil = f.now().Add(cfg.DemoteFor)
+		f.metrics.ProviderDemoted(f.names[i])
+	}
+}
+
+// retryable сообщает, что ошибка временная и стоит спросить следующего провайдера.
+func retryable(err error) bool {
+	_, ok := retryReason(err)
+	return ok
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// flightGroup объединяет одновременные запросы к backend по одному ключу:
+// первый вызывающий (leader) выполняет запрос, остальные ждут его результат.
+// В отличие от x/sync/singleflight позволяет одному leader закрыть сразу
+// несколько ключей одним batch-запросом.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done      chan struct{}
+	quote     price.Quote
+	err       error
+	abandoned bool // контекст leader завершился раньше, чем он получил результат
+}
+
+// join возвращает запрос в полёте по key. leader=true означает, что запроса не было:
+// вызывающий обязан выполнить его и вызвать finish.
+func (g *flightGroup) join(key string) (call *flightCall, leader bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		return c, false
+	}
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	return c, true
+}
+
+// finish публикует результат leader и снимает ключ с учёта; ctx — контекст запроса leader.
+func (g *flightGroup) finish(ctx context.Context, key string, c *flightCall, q price.Quote, err error) {
+	c.quote, c.err = q, err
+	c.abandoned = err != nil && ctx.Err() != nil
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// wait дожидается результата leader или отмены ctx.
+func (c *flightCall) wait(ctx context.Context) (price.Quote, error) {
+	select {
+	case <-c.done:
+		return c.quote, c.err
+	case <-ctx.Done():
+		return price.Quote{}, ctx.Err()
+	}
+}
+
+// leaderCancelled сообщает (после wait), что запрос leader прервался из-за его
+// собственного контекста, а контекст ожидающего ещё жив — тогда стоит повторить
+// запрос самому. Таймаут самого backend (например, http.Client) сюда не относится:
+// его ошибку ожидающие разделяют с leader.
+func (c *flightCall) leaderCancelled(ctx context.Context) bool {
+	return ctx.Err() == nil && c.abandoned
+}
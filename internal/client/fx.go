@@ -0,0 +1,417 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// Значения Quote.Source для курсов центробанков.
+const (
+	ECBName = "ecb" // Европейский центральный банк
+	CBRName = "cbr" // Центральный банк России
+)
+
+// Адреса ежедневных XML-таблиц курсов по умолчанию.
+const (
+	DefaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	DefaultCBRURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+)
+
+// fxTable — таблица официальных курсов на дату.
+type fxTable struct {
+	date  time.Time          // дата, на которую установлены курсы
+	rates map[string]float64 // единиц валюты за 1 единицу базовой; база → 1
+}
+
+// rate возвращает цену 1 id в vs по кросс-курсу через базовую валюту.
+func (t *fxTable) rate(id, vs string) (float64, bool) {
+	from, ok := t.rates[id]
+	if !ok {
+		return 0, false
+	}
+	to, ok := t.rates[vs]
+	if !ok {
+		return 0, false
+	}
+	return to / from, true
+}
+
+// fxFeed — ежедневная таблица курсов одного центробанка. Таблица держится в памяти
+// до ожидаемой публикации следующей: курсы меняются раз в рабочий день.
+type fxFeed struct {
+	name  string
+	url   string
+	parse func(data []byte, loc *time.Location) (fxTable, error)
+	retry retrier
+
+	// публикация: в рабочий день в hour:min по loc. ЦБ РФ датирует таблицу следующим
+	// рабочим днём (lead = 1), ЕЦБ — днём публикации (lead = 0).
+	hour, min int
+	loc       *time.Location
+	lead      int
+
+	mu        sync.Mutex
+	table     *fxTable
+	nextFetch time.Time
+}
+
+// nextPublication возвращает момент, когда ожидается таблица, следующая за таблицей на date.
+// Праздники не учитываются: если таблица в этот момент не вышла, FXClient повторит позже.
+func (f *fxFeed) nextPublication(date time.Time) time.Time {
+	d := date.AddDate(0, 0, 1-f.lead)
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		d = d.AddDate(0, 0, 1)
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), f.hour, f.min, 0, 0, f.loc)
+}
+
+// FXClient отдаёт курсы фиатных валют по официальным таблицам центробанков:
+// ЕЦБ (база EUR) и ЦБ РФ (база RUB). Кросс-курс берётся из первой таблицы,
+// в которой есть обе валюты: eur/usd — из ЕЦБ, usd/rub — из ЦБ РФ.
+// Quote.UpstreamUpdatedAt — дата, на которую установлен курс.
+type FXClient struct {
+	http  *http.Client
+	feeds []*fxFeed // в порядке приоритета
+	// retryInterval — пауза перед повторной загрузкой, если источник недоступен
+	// или ожидаемая таблица ещё не вышла (праздник, задержка публикации).
+	retryInterval time.Duration
+	now           func() time.Time
+}
+
+// NewFXClient создаёт клиент без повторов; см. SetRetryPolicy.
+// timeout ограничивает каждую попытку отдельно.
+func NewFXClient(timeout time.Duration) *FXClient {
+	noop := observability.NewNoopMetrics()
+	return &FXClient{
+		http: &http.Client{Timeout: timeout},
+		feeds: []*fxFeed{
+			{
+				name: ECBName, url: DefaultECBURL, parse: parseECB, retry: retrier{provider: ECBName, metrics: noop},
+				// около 16:00 CET; фиксированный +1 вместо Europe/Berlin, летом — с запасом в час
+				hour: 16, min: 0, loc: time.FixedZone("CET", 1*60*60), lead: 0,
+			},
+			{
+				name: CBRName, url: DefaultCBRURL, parse: parseCBR, retry: retrier{provider: CBRName, metrics: noop},
+				hour: 15, min: 30, loc: time.FixedZone("MSK", 3*60*60), lead: 1,
+			},
+		},
+		retryInterval: 30 * time.Minute,
+		now:           time.Now,
+	}
+}
+
+// GetPrice возвращает официальный курс: сколько vs стоит 1 id (например, id="usd", vs="rub").
+func (c *FXClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// GetQuote — то же, что GetPrice, но с метаданными.
+// Валюта, которой нет ни в одной таблице, — price.ErrUnknownID или price.ErrUnknownVS.
+func (c *FXClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	quotes, err := c.GetQuotes(ctx, []string{id}, []string{vs})
+	p := price.Pair{ID: id, VS: vs}
+	if q, ok := quotes[p]; ok {
+		return q, nil
+	}
+	if err != nil {
+		return price.Quote{}, err
+	}
+	return price.Quote{}, price.MissingPairError(p, c.known(id))
+}
+
+// GetQuotes возвращает курсы пар ids × vs; пары с неизвестными валютами в результат
+// не попадают. Если какой-то источник недоступен и без него часть пар не найдена,
+// ошибка возвращается вместе с частичным результатом.
+func (c *FXClient) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	tables := make([]*fxTable, len(c.feeds))
+	var errs []error
+	for i, f := range c.feeds {
+		t, err := c.table(ctx, f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.name, err))
+			continue
+		}
+		tables[i] = t
+	}
+
+	out := make(price.Quotes)
+	now := c.now()
+	missing := false
+	for _, id := range ids {
+		for _, v := range vs {
+			q, ok := c.lookup(tables, id, v, now)
+			if !ok {
+				missing = true
+				continue
+			}
+			out[price.Pair{ID: id, VS: v}] = q
+		}
+	}
+	if missing && len(errs) > 0 {
+		return out, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// lookup ищет курс id/vs в первой таблице, где он есть. FetchedAt — момент вызова:
+// котировка отдаётся сейчас, а дата таблицы остаётся в UpstreamUpdatedAt.
+func (c *FXClient) lookup(tables []*fxTable, id, vs string, now time.Time) (price.Quote, bool) {
+	for i, t := range tables {
+		if t == nil {
+			continue
+		}
+		if p, ok := t.rate(id, vs); ok {
+			return price.Quote{
+				ID:                id,
+				VS:                vs,
+				Price:             p,
+				Source:            c.feeds[i].name,
+				FetchedAt:         now,
+				UpstreamUpdatedAt: t.date,
+			}, true
+		}
+	}
+	return price.Quote{}, false
+}
+
+// known сообщает, есть ли валюта хотя бы в одной загруженной таблице.
+func (c *FXClient) known(id string) bool {
+	for _, f := range c.feeds {
+		f.mu.Lock()
+		t := f.table
+		f.mu.Unlock()
+		if t != nil {
+			if _, ok := t.rates[id]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// table возвращает актуальную таблицу источника, при необходимости загружая новую.
+// Если загрузка не удалась, а прежняя таблица есть, отдаётся она: официальный курс
+// за прошлый рабочий день лучше ошибки.
+func (c *FXClient) table(ctx context.Context, f *fxFeed) (*fxTable, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := c.now()
+	if f.table != nil && now.Before(f.nextFetch) {
+		return f.table, nil
+	}
+
+	t, err := c.fetch(ctx, f)
+	if err != nil {
+		if f.table != nil {
+			f.nextFetch = now.Add(c.retryInterval)
+			return f.table, nil
+		}
+		return nil, err
+	}
+	if f.table == nil || !t.date.Before(f.table.date) {
+		f.table = &t
+	}
+	f.nextFetch = f.nextPublication(f.table.date)
+	if !f.nextFetch.After(now) {
+		// следующая таблица уже должна была выйти, но её нет
+		f.nextFetch = now.Add(c.retryInterval)
+	}
+	return f.table, nil
+}
+
+// fetch загружает и разбирает таблицу источника с повторами по RetryPolicy.
+func (c *FXClient) fetch(ctx context.Context, f *fxFeed) (fxTable, error) {
+	var t fxTable
+	err := f.retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return transportError(ctx, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return statusError(resp)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return transportError(ctx, err)
+		}
+		t, err = f.parse(data, f.loc)
+		if err != nil {
+			return fmt.Errorf("%w: %w", price.ErrBadPayload, err)
+		}
+		return nil
+	})
+	return t, err
+}
+
+// parseECB разбирает eurofxref-daily.xml:
+// <Cube><Cube time="2024-01-05"><Cube currency="USD" rate="1.0921"/>...</Cube></Cube>.
+func parseECB(data []byte, loc *time.Location) (fxTable, error) {
+	var doc struct {
+		Cube struct {
+			Days []struct {
+				Time  string `xml:"time,attr"`
+				Rates []struct {
+					Currency string  `xml:"currency,attr"`
+					Rate     float64 `xml:"rate,attr"`
+				} `xml:"Cube"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fxTable{}, fmt.Errorf("decode ecb xml: %w", err)
+	}
+	if len(doc.Cube.Days) == 0 {
+		return fxTable{}, errors.New("ecb: no rates")
+	}
+	day := doc.Cube.Days[0] // первая — самая свежая
+	date, err := time.ParseInLocation("2006-01-02", day.Time, loc)
+	if err != nil {
+		return fxTable{}, fmt.Errorf("ecb: date %q: %w", day.Time, err)
+	}
+	rates := map[string]float64{"eur": 1}
+	for _, r := range day.Rates {
+		if r.Rate > 0 {
+			rates[strings.ToLower(r.Currency)] = r.Rate
+		}
+	}
+	return fxTable{date: date, rates: rates}, nil
+}
+
+// parseCBR разбирает XML_daily.asp (windows-1251): <ValCurs Date="06.01.2024">
+// <Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>90,6537</Value></Valute>...
+// Value — цена Nominal единиц валюты в рублях.
+func parseCBR(data []byte, loc *time.Location) (fxTable, error) {
+	var doc struct {
+		Date    string `xml:"Date,attr"`
+		Valutes []struct {
+			CharCode string `xml:"CharCode"`
+			Nominal  string `xml:"Nominal"`
+			Value    string `xml:"Value"`
+		} `xml:"Valute"`
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charsetReader
+	if err := dec.Decode(&doc); err != nil {
+		return fxTable{}, fmt.Errorf("decode cbr xml: %w", err)
+	}
+	date, err := time.ParseInLocation("02.01.2006", doc.Date, loc)
+	if err != nil {
+		return fxTable{}, fmt.Errorf("cbr: date %q: %w", doc.Date, err)
+	}
+	rates := map[string]float64{"rub": 1}
+	for _, v := range doc.Valutes {
+		nominal, err := strconv.ParseFloat(strings.TrimSpace(v.Nominal), 64)
+		if err != nil {
+			return fxTable{}, fmt.Errorf("cbr: %s nominal %q: %w", v.CharCode, v.Nominal, err)
+		}
+		value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v.Value), ",", "."), 64)
+		if err != nil {
+			return fxTable{}, fmt.Errorf("cbr: %s value %q: %w", v.CharCode, v.Value, err)
+		}
+		if nominal > 0 && value > 0 {
+			rates[strings.ToLower(v.CharCode)] = nominal / value
+		}
+	}
+	if len(rates) == 1 {
+		return fxTable{}, errors.New("cbr: no rates")
+	}
+	return fxTable{date: date, rates: rates}, nil
+}
+
+// charsetReader поддерживает windows-1251 (кодировка ЦБ РФ). Декодируется только
+// кириллица; прочие не-ASCII символы заменяются на U+FFFD — в читаемых полях их нет.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if !strings.EqualFold(charset, "windows-1251") {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(data)*2)
+	for _, b := range data {
+		var r rune
+		switch {
+		case b < 0x80:
+			r = rune(b)
+		case b >= 0xC0: // А..я
+			r = 0x410 + rune(b-0xC0)
+		case b == 0xA8:
+			r = 'Ё'
+		case b == 0xB8:
+			r = 'ё'
+		default:
+			r = utf8.RuneError
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return bytes.NewReader(out), nil
+}
+
+// SetFeedURL переопределяет адрес таблицы источника feed (ECBName, CBRName) — для тестов
+// или зеркал. Пустой адрес и неизвестный источник игнорируются.
+func (c *FXClient) SetFeedURL(feed, u string) {
+	if u == "" {
+		return
+	}
+	for _, f := range c.feeds {
+		if f.name == feed {
+			f.url = u
+		}
+	}
+}
+
+// SetHTTPClient allows injecting a custom http.Client (optional - useful for tests).
+func (c *FXClient) SetHTTPClient(h *http.Client) {
+	if h == nil {
+		return
+	}
+	c.http = h
+}
+
+// SetRetryPolicy включает повторы запросов (429, 5xx, сетевые ошибки) по политике p.
+func (c *FXClient) SetRetryPolicy(p RetryPolicy) {
+	for _, f := range c.feeds {
+		f.retry.policy = p
+	}
+}
+
+// SetMetrics задаёт метрики для учёта повторов. nil игнорируется.
+func (c *FXClient) SetMetrics(m observability.Metrics) {
+	if m == nil {
+		return
+	}
+	for _, f := range c.feeds {
+		f.retry.metrics = m
+	}
+}
+
+// SetClock подменяет источник текущего времени (для тестов публикации таблиц).
+func (c *FXClient) SetClock(now func() time.Time) {
+	if now == nil {
+		return
+	}
+	c.now = now
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// KrakenName — значение Quote.Source для цен, полученных от Kraken.
+const KrakenName = "kraken"
+
+// KrakenSymbols — тикеры Kraken, отличающиеся от реестра asset.
+func KrakenSymbols() SymbolMap {
+	return SymbolMap{"bitcoin": "XBT", "dogecoin": "XDG"}
+}
+
+// KrakenClient получает цены из публичного API Kraken (/0/public/Ticker).
+// Рынок пары — тикеры подряд: bitcoin/usd → XBTUSD.
+type KrakenClient struct {
+	exchange
+}
+
+// NewKrakenClient создаёт клиент без повторов; см. SetRetryPolicy.
+// timeout ограничивает каждую попытку отдельно.
+func NewKrakenClient(timeout time.Duration) *KrakenClient {
+	return &KrakenClient{exchange: newExchange(KrakenName, "https://api.kraken.com", KrakenSymbols(), timeout)}
+}
+
+// GetPrice возвращает последнюю цену сделки на рынке id/vs.
+func (c *KrakenClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+// krakenTicker — ответ /0/public/Ticker. Kraken сообщает об ошибках в поле error
+// при статусе 200; ключ в result — собственное имя рынка (XBTUSD → XXBTZUSD).
+type krakenTicker struct {
+	Error  []string `json:"error"`
+	Result map[string]struct {
+		Last   []string `json:"c"` // [цена, объём] последней сделки
+		Volume []string `json:"v"` // [объём сегодня, объём за 24 часа]
+	} `json:"result"`
+}
+
+// GetQuote — то же, что GetPrice, но с метаданными.
+// Ошибки типизированы: рынка нет — price.ErrUnknownVS, лимит — price.ErrRateLimited и т.д.
+func (c *KrakenClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	base, quote, err := c.market(id, vs)
+	if err != nil {
+		return price.Quote{}, err
+	}
+	pair := base + quote
+	u := c.baseURL + "/0/public/Ticker?" + url.Values{"pair": {pair}}.Encode()
+
+	var body krakenTicker
+	err = c.get(ctx, u, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return statusError(resp)
+		}
+		body = krakenTicker{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return fmt.Errorf("%w: decode json: %w", price.ErrBadPayload, err)
+		}
+		if len(body.Error) > 0 {
+			return c.apiError(id, vs, pair, body.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return price.Quote{}, err
+	}
+
+	// запрошен один рынок, поэтому и в result ровно одна запись
+	for _, t := range body.Result {
+		if len(t.Last) == 0 {
+			break
+		}
+		p, err := strconv.ParseFloat(t.Last[0], 64)
+		if err != nil {
+			return price.Quote{}, fmt.Errorf("%w: price %q: %w", price.ErrBadPayload, t.Last[0], err)
+		}
+		q := c.quote(id, vs, p)
+		if len(t.Volume) > 1 {
+			q.Volume, _ = strconv.ParseFloat(t.Volume[1], 64) // объём необязателен
+		}
+		return q, nil
+	}
+	return price.Quote{}, fmt.Errorf("%w: no ticker for %s", price.ErrBadPayload, pair)
+}
+
+// apiError переводит ошибки из поля error ответа Kraken в типизированные ошибки пакета price.
+func (c *KrakenClient) apiError(id, vs, pair string, errs []string) error {
+	msg := strings.Join(errs, "; ")
+	switch {
+	case strings.Contains(msg, "Unknown asset pair"):
+		return c.noMarket(id, vs, pair)
+	case strings.Contains(msg, "Rate limit exceeded"), strings.Contains(msg, "Too many requests"):
+		return &price.RateLimitError{}
+	case strings.HasPrefix(msg, "EService:"):
+		return fmt.Errorf("%w: kraken: %s", price.ErrUpstreamUnavailable, msg)
+	default:
+		return fmt.Errorf("kraken: %s", msg)
+	}
+}
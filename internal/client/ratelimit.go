@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// RateLimit задаёт token bucket для исходящих запросов.
+type RateLimit struct {
+	Rate  float64 // запросов в секунду (например, 30/60 для 30 запросов в минуту)
+	Burst int     // ёмкость bucket
+	Wait  bool    // true — ждать токен до дедлайна ctx, false — сразу отклонять
+}
+
+// CoinGeckoFreeTier — лимит бесплатного тарифа CoinGecko (~30 запросов в минуту).
+func CoinGeckoFreeTier() RateLimit {
+	return RateLimit{Rate: 0.5, Burst: 5, Wait: true}
+}
+
+// Limiter выдаёт разрешение на очередной запрос к провайдеру (реализуется RateLimitedClient).
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimitedClient — декоратор price.PriceClient, ограничивающий частоту вызовов backend.
+// Один вызов backend (в том числе batch GetQuotes и GetPairs) расходует один токен;
+// повторы внутри backend оплачиваются отдельно через Wait (см. CoinGeckoClient.SetRetryLimiter).
+// Если токена нет, вызывающий либо ждёт (RateLimit.Wait), либо получает
+// *price.RateLimitError с RetryAfter до появления токена.
+type RateLimitedClient struct {
+	name    string
+	backend price.PriceClient
+	limit   RateLimit
+	metrics observability.Metrics
+
+	mu     sync.Mutex
+	tokens float64 // может уходить в минус: это уже выданные резервации
+	last   time.Time
+}
+
+// NewRateLimitedClient оборачивает backend. name используется в метриках.
+// metrics может быть nil — тогда будет использован noop.
+func NewRateLimitedClient(name string, backend price.PriceClient, l RateLimit, m observability.Metrics) *RateLimitedClient {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	if l.Burst <= 0 {
+		l.Burst = 1
+	}
+	return &RateLimitedClient{
+		name:    name,
+		backend: backend,
+		limit:   l,
+		metrics: m,
+		tokens:  float64(l.Burst),
+		last:    time.Now(),
+	}
+}
+
+func (c *RateLimitedClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := c.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+func (c *RateLimitedClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	if err := c.Wait(ctx); err != nil {
+		return price.Quote{}, err
+	}
+	return price.FetchQuote(ctx, c.backend, id, vs)
+}
+
+func (c *RateLimitedClient) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	if err := c.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return price.FetchQuotes(ctx, c.backend, ids, vs)
+}
+
+func (c *RateLimitedClient) GetPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, error) {
+	if err := c.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return price.FetchPairs(ctx, c.backend, pairs)
+}
+
+// SetLimit меняет лимит на лету. Накопленные токены сохраняются, но не больше нового Burst.
+func (c *RateLimitedClient) SetLimit(l RateLimit) {
+	if l.Burst <= 0 {
+		l.Burst = 1
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refill(time.Now()) // начисляем по старой скорости за прошедшее время
+	c.limit = l
+	if burst := float64(l.Burst); c.tokens > burst {
+		c.tokens = burst
+	}
+}
+
+// Wait получает токен, при необходимости дожидаясь его (RateLimit.Wait). Если токена
+// нет и ждать нельзя, возвращает *price.RateLimitError с RetryAfter до его появления.
+func (c *RateLimitedClient) Wait(ctx context.Context) error {
+	wait, ok := c.reserve(ctx)
+	if !ok {
+		c.metrics.RateLimitRejected(c.name)
+		return &price.RateLimitError{RetryAfter: wait}
+	}
+	if wait <= 0 {
+		c.metrics.ObserveRateLimitWait(c.name, 0)
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		c.metrics.ObserveRateLimitWait(c.name, wait)
+		return nil
+	case <-ctx.Done():
+		c.cancelReservation()
+		c.metrics.RateLimitRejected(c.name)
+		return ctx.Err()
+	}
+}
+
+// reserve резервирует токен и возвращает, сколько ждать до его появления.
+// ok=false — резервация не сделана: ждать нельзя или ожидание не уложится в дедлайн ctx.
+func (c *RateLimitedClient) reserve(ctx context.Context) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.refill(now)
+	if c.tokens >= 1 {
+		c.tokens--
+		return 0, true
+	}
+
+	wait := c.waitFor(1 - c.tokens)
+	if !c.limit.Wait {
+		return wait, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(now) < wait {
+		return wait, false
+	}
+	c.tokens--
+	return wait, true
+}
+
+// cancelReservation возвращает токен, за которым вызывающий так и не пришёл.
+func (c *RateLimitedClient) cancelReservation() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refill(time.Now())
+	c.tokens++
+}
+
+// refill начисляет токены за время с последнего обращения (вызывается под c.mu).
+func (c *RateLimitedClient) refill(now time.Time) {
+	elapsed := now.Sub(c.last).Seconds()
+	c.last = now
+	if elapsed <= 0 || c.limit.Rate <= 0 {
+		return
+	}
+	c.tokens += elapsed * c.limit.Rate
+	if burst := float64(c.limit.Burst); c.tokens > burst {
+		c.tokens = burst
+	}
+}
+
+func (c *RateLimitedClient) waitFor(tokens float64) time.Duration {
+	if c.limit.Rate <= 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(tokens / c.limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// RetryPolicy описывает повторные попытки запросов к провайдеру.
+// Повторяются только ответы 429, 5xx и сетевые ошибки; нулевое значение — без повторов.
+type RetryPolicy struct {
+	MaxAttempts int           // всего попыток, включая первую; <= 1 — без повторов
+	BaseDelay   time.Duration // пауза перед первым повтором, дальше удваивается
+	MaxDelay    time.Duration // верхняя граница паузы
+	Jitter      float64       // доля случайного разброса паузы, 0..1
+
+	// MaxRetryAfter — самая долгая пауза, которую можно выдержать по Retry-After провайдера;
+	// если провайдер просит ждать дольше, ошибка возвращается сразу. 0 — используется MaxDelay.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy — разумные значения для публичных API.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.2,
+
+		MaxRetryAfter: 5 * time.Second,
+	}
+}
+
+// retrier выполняет fn с повторами согласно policy.
+type retrier struct {
+	policy   RetryPolicy
+	provider string
+	metrics  observability.Metrics
+	limiter  Limiter // nil — повторы не ограничиваются
+}
+
+func (r *retrier) do(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= r.policy.MaxAttempts {
+			return err
+		}
+		reason, ok := retryReason(err)
+		if !ok || ctx.Err() != nil {
+			return err
+		}
+
+		wait := r.backoff(attempt)
+		var rl *price.RateLimitError
+		if errors.As(err, &rl) && rl.RetryAfter > 0 {
+			if limit := r.maxRetryAfter(); limit > 0 && rl.RetryAfter > limit {
+				return err
+			}
+			wait = rl.RetryAfter
+		}
+		// Не ждём, если пауза заведомо не уложится в дедлайн вызывающего.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+
+		r.metrics.BackendRetry(r.provider, reason)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		// повтор — такой же запрос к провайдеру: без токена лимита его не делаем
+		if r.limiter != nil && r.limiter.Wait(ctx) != nil {
+			return err
+		}
+	}
+}
+
+// maxRetryAfter возвращает предел паузы по Retry-After (0 — без предела).
+func (r *retrier) maxRetryAfter() time.Duration {
+	if r.policy.MaxRetryAfter > 0 {
+		return r.policy.MaxRetryAfter
+	}
+	return r.policy.MaxDelay
+}
+
+// backoff возвращает экспоненциальную паузу перед повтором номер attempt (с 1) с учётом jitter.
+func (r *retrier) backoff(attempt int) time.Duration {
+	d := r.policy.BaseDelay << (attempt - 1)
+	if r.policy.MaxDelay > 0 && (d > r.policy.MaxDelay || d <= 0) {
+		d = r.policy.MaxDelay
+	}
+	if r.policy.Jitter > 0 && d > 0 {
+		delta := float64(d) * r.policy.Jitter
+		d += time.Duration(delta * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// retryReason сообщает, стоит ли повторять запрос после err, и причину для метрик.
+func retryReason(err error) (string, bool) {
+	switch {
+	case errors.Is(err, price.ErrRateLimited):
+		return "rate_limited", true
+	case errors.Is(err, price.ErrUpstreamUnavailable):
+		return "unavailable", true
+	default:
+		return "", false
+	}
+}
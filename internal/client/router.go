@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+// FXName — имя провайдера курсов центробанков (FXClient) в правилах маршрутизации.
+// Quote.Source при этом — конкретный источник: ECBName или CBRName.
+const FXName = "fx"
+
+// AnyAsset в правиле маршрутизации совпадает с любым id или vs.
+const AnyAsset = "*"
+
+// Route — правило маршрутизации: пары, совпавшие по ID и VS, обслуживают Providers
+// в указанном порядке. ID и VS — идентификатор актива, класс актива из реестра
+// asset (crypto, stablecoin, fiat) или AnyAsset.
+// В конфигурации записывается как "id/vs=provider,provider": "fiat/fiat=fx", "*/*=coingecko".
+type Route struct {
+	ID        string
+	VS        string
+	Providers []string
+}
+
+// ParseRoute разбирает правило вида "id/vs=provider,provider".
+func ParseRoute(s string) (Route, error) {
+	match, providers, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "=")
+	id, vs, okPair := strings.Cut(strings.TrimSpace(match), "/")
+	if !ok || !okPair {
+		return Route{}, fmt.Errorf("invalid route %q, want id/vs=provider,...", s)
+	}
+	r := Route{ID: strings.TrimSpace(id), VS: strings.TrimSpace(vs)}
+	for _, p := range strings.Split(providers, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			r.Providers = append(r.Providers, p)
+		}
+	}
+	if r.ID == "" || r.VS == "" || len(r.Providers) == 0 {
+		return Route{}, fmt.Errorf("invalid route %q, want id/vs=provider,...", s)
+	}
+	return r, nil
+}
+
+func (r Route) String() string {
+	return r.ID + "/" + r.VS + "=" + strings.Join(r.Providers, ",")
+}
+
+// Matches сообщает, подходит ли правило для пары id/vs.
+func (r Route) Matches(id, vs string) bool {
+	return matchAsset(r.ID, id) && matchAsset(r.VS, vs)
+}
+
+func matchAsset(pattern, id string) bool {
+	if pattern == AnyAsset || pattern == id {
+		return true
+	}
+	a, ok := asset.Lookup(id)
+	return ok && string(a.Class) == pattern
+}
+
+// Router выбирает провайдера цены по правилам Route: первое совпавшее правило
+// задаёт провайдеров, которые пробуются по порядку. К следующему провайдеру
+// Router переходит, только если текущий не знает пару (price.ErrUnknownID,
+// price.ErrUnknownVS); прочие ошибки возвращаются сразу.
+// Quote.Source — ответивший провайдер (или его источник, если он его сообщает).
+type Router struct {
+	providers map[string]price.PriceClient
+	routes    atomic.Pointer[[]Route]
+	metrics   observability.Metrics
+}
+
+// NewRouter создаёт Router. Все провайдеры из routes должны быть в providers.
+// metrics может быть nil — тогда будет использован noop.
+func NewRouter(providers map[string]price.PriceClient, routes []Route, m observability.Metrics) (*Router, error) {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	r := &Router{providers: providers, metrics: m}
+	if err := r.SetRoutes(routes); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetRoutes заменяет правила на лету. Правила с неизвестным провайдером отклоняются целиком.
+func (r *Router) SetRoutes(routes []Route) error {
+	for _, rt := range routes {
+		for _, name := range rt.Providers {
+			if _, ok := r.providers[name]; !ok {
+				return fmt.Errorf("route %s: unknown provider %q", rt, name)
+			}
+		}
+	}
+	routes = slices.Clone(routes)
+	r.routes.Store(&routes)
+	return nil
+}
+
+// Providers возвращает провайдеров для пары id/vs в порядке fallback (nil — нет правила).
+func (r *Router) Providers(id, vs string) []string {
+	for _, rt := range *r.routes.Load() {
+		if rt.Matches(id, vs) {
+			return rt.Providers
+		}
+	}
+	return nil
+}
+
+func (r *Router) GetPrice(ctx context.Context, id, vs string) (float64, error) {
+	q, err := r.GetQuote(ctx, id, vs)
+	if err != nil {
+		return 0, err
+	}
+	return q.Price, nil
+}
+
+func (r *Router) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	names := r.Providers(id, vs)
+	if len(names) == 0 {
+		return price.Quote{}, noRouteError(id, vs)
+	}
+	var err error
+	for _, name := range names {
+		var q price.Quote
+		q, err = price.FetchQuote(ctx, r.providers[name], id, vs)
+		switch {
+		case err == nil:
+			r.metrics.RouteResult(name, "served")
+			return withSource(q, name), nil
+		case unsupported(err):
+			r.metrics.RouteResult(name, "fallback")
+		default:
+			r.metrics.RouteResult(name, "error")
+			return price.Quote{}, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return price.Quote{}, err
+}
+
+// GetQuotes — GetPairs для пар ids × vs.
+func (r *Router) GetQuotes(ctx context.Context, ids, vs []string) (price.Quotes, error) {
+	return r.GetPairs(ctx, price.CrossPairs(ids, vs))
+}
+
+// GetPairs группирует пары по правилам и запрашивает каждую группу у провайдера
+// через price.FetchPairs; пары, которых провайдер не знает, переходят к следующему.
+// Частичный результат возвращается вместе с ошибкой каждой пары от её провайдера
+// (price.PairErrors).
+func (r *Router) GetPairs(ctx context.Context, pairs []price.Pair) (price.Quotes, error) {
+	// пары, ожидающие ответа, по провайдеру; step — позиция провайдера в правиле пары
+	type pending struct {
+		pair  price.Pair
+		names []string
+		step  int
+	}
+	var queue []pending
+	for _, p := range pairs {
+		if names := r.Providers(p.ID, p.VS); len(names) > 0 {
+			queue = append(queue, pending{pair: p, names: names})
+		}
+	}
+
+	out := make(price.Quotes)
+	errs := make(price.PairErrors)
+	for len(queue) > 0 {
+		// одна волна: по одному batch-вызову на провайдера
+		byProvider := make(map[string][]pending)
+		var order []string
+		for _, p := range queue {
+			name := p.names[p.step]
+			if _, ok := byProvider[name]; !ok {
+				order = append(order, name)
+			}
+			byProvider[name] = append(byProvider[name], p)
+		}
+		queue = queue[:0:0]
+
+		for _, name := range order {
+			group := byProvider[name]
+			pairs := make([]price.Pair, len(group))
+			for i, p := range group {
+				pairs[i] = p.pair
+			}
+			quotes, err := price.FetchPairs(ctx, r.providers[name], pairs)
+			for _, p := range group {
+				if q, ok := quotes[p.pair]; ok {
+					r.metrics.RouteResult(name, "served")
+					out[p.pair] = withSource(q, name)
+					continue
+				}
+				if pairErr := price.ErrorFor(err, p.pair); pairErr != nil && !unsupported(pairErr) {
+					r.metrics.RouteResult(name, "error")
+					errs[p.pair] = fmt.Errorf("%s: %w", name, pairErr)
+					continue
+				}
+				r.metrics.RouteResult(name, "fallback")
+				if p.step+1 < len(p.names) {
+					p.step++
+					queue = append(queue, p)
+				}
+			}
+		}
+	}
+	return out, errs.Err()
+}
+
+// unsupported сообщает, что провайдер не знает пару и стоит спросить следующего.
+// Для price.PairErrors — что ни одну из пар.
+func unsupported(err error) bool {
+	var pe price.PairErrors
+	if errors.As(err, &pe) {
+		for _, err := range pe {
+			if !unsupported(err) {
+				return false
+			}
+		}
+		return len(pe) > 0
+	}
+	return errors.Is(err, price.ErrUnknownID) || errors.Is(err, price.ErrUnknownVS)
+}
+
+func withSource(q price.Quote, provider string) price.Quote {
+	if q.Source == "" {
+		q.Source = provider
+	}
+	return q
+}
+
+func noRouteError(id, vs string) error {
+	return fmt.Errorf("%w: no provider routes %s/%s", price.ErrUnknownID, id, vs)
+}
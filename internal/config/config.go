@@ -0,0 +1,446 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"gopkg.in/yaml.v3"
+)
+
+// Config — полная конфигурация приложения.
+type Config struct {
+	HTTP      HTTPConfig      `yaml:"http"`
+	Log       LogConfig       `yaml:"log"`
+	Cache     CacheConfig     `yaml:"cache"`
+	CoinGecko CoinGeckoConfig `yaml:"coingecko"`
+	Exchanges ExchangesConfig `yaml:"exchanges"`
+	FX        FXConfig        `yaml:"fx"`
+	Routing   RoutingConfig   `yaml:"routing"`
+	Aggregate AggregateConfig `yaml:"aggregate"`
+	Failover  FailoverConfig  `yaml:"failover"`
+	Service   ServiceConfig   `yaml:"service"`
+	Rates     RatesConfig     `yaml:"rates"`
+	Poller    PollerConfig    `yaml:"poller"`
+	Leader    LeaderConfig    `yaml:"leader"`
+}
+
+type HTTPConfig struct {
+	Addr            string        `yaml:"addr"`
+	RequestTimeout  time.Duration `yaml:"request_timeout"`  // бюджет одного запроса к ценам
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // ожидание активных запросов при остановке
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"` // debug, info, warn, error
+}
+
+type CacheConfig struct {
+	Backend     string        `yaml:"backend"` // redis, memory, tiered; пусто — redis, если задан RedisAddr, иначе memory
+	RedisAddr   string        `yaml:"redis_addr"`
+	MaxEntries  int           `yaml:"max_entries"` // in-memory кэш или L1; 0 — без ограничения
+	L1TTL       time.Duration `yaml:"l1_ttl"`
+	FreshFor    time.Duration `yaml:"fresh_for"`
+	StaleFor    time.Duration `yaml:"stale_for"`
+	MaxStale    time.Duration `yaml:"max_stale"`
+	NegativeTTL time.Duration `yaml:"negative_ttl"` // 0 — без negative caching
+
+	// ClassFreshness переопределяет окна свежести для пар классов активов (client.PairClass).
+	ClassFreshness ClassFreshnessList `yaml:"class_freshness"`
+}
+
+type CoinGeckoConfig struct {
+	BaseURL string        `yaml:"base_url"`
+	Timeout time.Duration `yaml:"timeout"` // на одну попытку запроса
+	Rate    float64       `yaml:"rate"`    // запросов в секунду
+	Burst   int           `yaml:"burst"`
+}
+
+type ExchangesConfig struct {
+	Timeout     time.Duration `yaml:"timeout"` // на одну попытку запроса
+	BinanceURL  string        `yaml:"binance_url"`
+	KrakenURL   string        `yaml:"kraken_url"`
+	CoinbaseURL string        `yaml:"coinbase_url"`
+}
+
+type FXConfig struct {
+	Enabled bool          `yaml:"enabled"` // пары фиат/фиат — из курсов центробанков, а не CoinGecko
+	ECBURL  string        `yaml:"ecb_url"`
+	CBRURL  string        `yaml:"cbr_url"`
+	Timeout time.Duration `yaml:"timeout"` // на одну попытку запроса
+}
+
+type RoutingConfig struct {
+	Rules RouteList `yaml:"rules"` // первое совпавшее правило задаёт провайдеров пары
+}
+
+type AggregateConfig struct {
+	Providers    StringList `yaml:"providers"`     // провайдеры, которых опрашивает aggregate
+	MinSources   int        `yaml:"min_sources"`   // кворум после отбраковки выбросов
+	MaxDeviation float64    `yaml:"max_deviation"` // допустимое отклонение от медианы, доля; 0 — без отбраковки
+	Method       string     `yaml:"method"`        // median, vwap
+}
+
+type FailoverConfig struct {
+	Providers   StringList    `yaml:"providers"`    // провайдеры failover по убыванию приоритета
+	Decay       float64       `yaml:"decay"`        // вес последнего результата в оценке здоровья, (0, 1]
+	DemoteBelow float64       `yaml:"demote_below"` // оценка, ниже которой провайдер понижается
+	DemoteFor   time.Duration `yaml:"demote_for"`
+}
+
+type ServiceConfig struct {
+	Concurrency int           `yaml:"concurrency"`
+	PairTimeout time.Duration `yaml:"pair_timeout"`
+}
+
+type RatesConfig struct {
+	DefaultPairs PairList `yaml:"default_pairs"` // пары /rates без параметров
+}
+
+type PollerConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Pairs    PairList      `yaml:"pairs"` // пусто — Rates.DefaultPairs
+	Interval time.Duration `yaml:"interval"`
+	Jitter   float64       `yaml:"jitter"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+type LeaderConfig struct {
+	Key           string        `yaml:"key"`
+	TTL           time.Duration `yaml:"ttl"`
+	RenewInterval time.Duration `yaml:"renew_interval"`
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
+// Default возвращает конфигурацию по умолчанию.
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			RequestTimeout:  5 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Log: LogConfig{Level: "debug"},
+		Cache: CacheConfig{
+			MaxEntries:  10000,
+			L1TTL:       5 * time.Second,
+			FreshFor:    time.Minute,
+			StaleFor:    30 * time.Second,
+			MaxStale:    10 * time.Minute,
+			NegativeTTL: 30 * time.Second,
+			// курсы центробанков обновляются раз в день
+			ClassFreshness: ClassFreshnessList{
+				{Class: asset.Fiat, Freshness: client.Freshness{FreshFor: time.Hour, StaleFor: time.Hour, MaxStale: 24 * time.Hour}},
+			},
+		},
+		CoinGecko: CoinGeckoConfig{
+			BaseURL: "https://api.coingecko.com",
+			Timeout: 5 * time.Second,
+			Rate:    0.5,
+			Burst:   5,
+		},
+		Exchanges: ExchangesConfig{
+			Timeout:     5 * time.Second,
+			BinanceURL:  "https://api.binance.com",
+			KrakenURL:   "https://api.kraken.com",
+			CoinbaseURL: "https://api.coinbase.com",
+		},
+		FX: FXConfig{
+			Enabled: true,
+			ECBURL:  client.DefaultECBURL,
+			CBRURL:  client.DefaultCBRURL,
+			Timeout: 5 * time.Second,
+		},
+		Routing: RoutingConfig{
+			Rules: RouteList{
+				{ID: "fiat", VS: "fiat", Providers: []string{client.FXName}},
+				{ID: client.AnyAsset, VS: client.AnyAsset, Providers: []string{client.FailoverName}},
+			},
+		},
+		Aggregate: AggregateConfig{
+			Providers:    StringList{client.BinanceName, client.KrakenName, client.CoinbaseName},
+			MinSources:   2,
+			MaxDeviation: 0.02,
+			Method:       string(client.AggregateMedian),
+		},
+		Failover: FailoverConfig{
+			Providers:   StringList{client.CoinGeckoName, client.BinanceName, client.KrakenName, client.CoinbaseName},
+			Decay:       0.5,
+			DemoteBelow: 0.3,
+			DemoteFor:   time.Minute,
+		},
+		Service: ServiceConfig{
+			Concurrency: 10,
+			PairTimeout: 2 * time.Second,
+		},
+		Rates: RatesConfig{
+			DefaultPairs: PairList{
+				{ID: "bitcoin", VS: "usd"},
+				{ID: "ethereum", VS: "usd"},
+				{ID: "usd", VS: "rub"},
+			},
+		},
+		Poller: PollerConfig{
+			Enabled:  true,
+			Interval: 30 * time.Second,
+			Jitter:   0.1,
+			Timeout:  10 * time.Second,
+		},
+		Leader: LeaderConfig{
+			Key:           "leader:poller",
+			TTL:           15 * time.Second,
+			RenewInterval: 5 * time.Second,
+			RetryInterval: 5 * time.Second,
+		},
+	}
+}
+
+// Providers — имена провайдеров цен, доступных в routing.rules.
+var Providers = []string{client.CoinGeckoName, client.BinanceName, client.KrakenName, client.CoinbaseName, client.FXName, client.AggregateName, client.FailoverName}
+
+// CacheBackend возвращает реализацию кэша с учётом значения по умолчанию.
+func (c Config) CacheBackend() string {
+	if c.Cache.Backend != "" {
+		return c.Cache.Backend
+	}
+	if c.Cache.RedisAddr != "" {
+		return "redis"
+	}
+	return "memory"
+}
+
+// PollerPairs возвращает пары фонового обновления с учётом значения по умолчанию.
+func (c Config) PollerPairs() PairList {
+	if len(c.Poller.Pairs) > 0 {
+		return c.Poller.Pairs
+	}
+	return c.Rates.DefaultPairs
+}
+
+// String возвращает конфигурацию в виде YAML (для вывода эффективной конфигурации при старте).
+func (c Config) String() string {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("<config: %v>", err)
+	}
+	return string(data)
+}
+
+// FileEnv и FileFlag задают путь к файлу конфигурации.
+const (
+	FileEnv  = "CONFIG_FILE"
+	FileFlag = "config"
+)
+
+// Load собирает конфигурацию в порядке возрастания приоритета: значения по умолчанию,
+// YAML-файл (-config или CONFIG_FILE), переменные окружения, флаги командной строки.
+// Результат проверяется через Validate. Для -h возвращается flag.ErrHelp.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg, err := Read(args, getenv)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Read — то же, что Load, но без Validate: при перечитывании на лету проверять
+// нужно конфигурацию, в которую уже возвращены поля, требующие перезапуска (см. Reloadable).
+func Read(args []string, getenv func(string) string) (Config, error) {
+	path, err := Path(args, getenv)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := applyEnv(&cfg, getenv); err != nil {
+		return Config{}, err
+	}
+	if err := newFlagSet(&cfg).Parse(args); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Path возвращает путь к файлу конфигурации из -config или CONFIG_FILE ("" — файла нет).
+// Для -h печатает справку и возвращает flag.ErrHelp.
+func Path(args []string, getenv func(string) string) (string, error) {
+	// Проход по флагам только ради пути к файлу; остальные значения не используются.
+	scratch := Default()
+	pre := newFlagSet(&scratch)
+	pre.SetOutput(&bytes.Buffer{})
+	if err := pre.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs := newFlagSet(&scratch)
+			fs.SetOutput(os.Stderr)
+			fs.Usage()
+		}
+		return "", err
+	}
+	if path := pre.Lookup(FileFlag).Value.String(); path != "" {
+		return path, nil
+	}
+	return getenv(FileEnv), nil
+}
+
+// loadFile накладывает YAML-файл на cfg. Неизвестные ключи считаются ошибкой.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv накладывает заданные переменные окружения на cfg.
+func applyEnv(cfg *Config, getenv func(string) string) error {
+	for _, f := range fields(cfg) {
+		v := getenv(f.env)
+		if v == "" {
+			continue
+		}
+		if err := f.value.Set(v); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", f.env, v, err)
+		}
+	}
+	return nil
+}
+
+func newFlagSet(cfg *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String(FileFlag, "", "path to YAML config file (env "+FileEnv+")")
+	for _, f := range fields(cfg) {
+		fs.Var(f.value, f.flagName(), f.usage+" (env "+f.env+")")
+	}
+	return fs
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные проблемы сразу.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr must be set")
+	check(c.HTTP.RequestTimeout > 0, "http.request_timeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+	check(slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level),
+		"log.level must be debug, info, warn or error, got %q", c.Log.Level)
+
+	backend := c.CacheBackend()
+	check(slices.Contains([]string{"redis", "memory", "tiered"}, backend),
+		"cache.backend must be redis, memory or tiered, got %q", backend)
+	check(backend == "memory" || c.Cache.RedisAddr != "", "cache.redis_addr is required for cache.backend %q", backend)
+	check(c.Cache.MaxEntries >= 0, "cache.max_entries must not be negative")
+	check(backend != "tiered" || c.Cache.L1TTL > 0, "cache.l1_ttl must be positive")
+	check(c.Cache.FreshFor > 0, "cache.fresh_for must be positive")
+	check(c.Cache.StaleFor >= 0, "cache.stale_for must not be negative")
+	check(c.Cache.MaxStale >= 0, "cache.max_stale must not be negative")
+	check(c.Cache.NegativeTTL >= 0, "cache.negative_ttl must not be negative")
+	seenClass := make(map[asset.Class]bool)
+	for _, cf := range c.Cache.ClassFreshness {
+		check(slices.Contains([]asset.Class{asset.Crypto, asset.Stablecoin, asset.Fiat}, cf.Class),
+			"cache.class_freshness: unknown asset class %q", cf.Class)
+		check(!seenClass[cf.Class], "cache.class_freshness: duplicate class %q", cf.Class)
+		seenClass[cf.Class] = true
+		check(cf.FreshFor > 0, "cache.class_freshness %s: fresh_for must be positive", cf.Class)
+		check(cf.StaleFor >= 0 && cf.MaxStale >= 0, "cache.class_freshness %s: windows must not be negative", cf.Class)
+	}
+
+	check(c.CoinGecko.BaseURL != "", "coingecko.base_url must be set")
+	check(c.CoinGecko.Timeout > 0, "coingecko.timeout must be positive")
+	check(c.CoinGecko.Rate > 0, "coingecko.rate must be positive")
+	check(c.CoinGecko.Burst > 0, "coingecko.burst must be positive")
+
+	if c.FX.Enabled {
+		check(c.FX.ECBURL != "" && c.FX.CBRURL != "", "fx.ecb_url and fx.cbr_url must be set")
+		check(c.FX.Timeout > 0, "fx.timeout must be positive")
+	}
+
+	check(c.Exchanges.Timeout > 0, "exchanges.timeout must be positive")
+	check(c.Exchanges.BinanceURL != "" && c.Exchanges.KrakenURL != "" && c.Exchanges.CoinbaseURL != "",
+		"exchanges.binance_url, exchanges.kraken_url and exchanges.coinbase_url must be set")
+	check(len(c.Routing.Rules) > 0, "routing.rules must not be empty")
+	for _, r := range c.Routing.Rules {
+		for _, p := range r.Providers {
+			check(slices.Contains(Providers, p), "routing.rules: %s: unknown provider %q", r, p)
+			check(p != client.FXName || c.FX.Enabled, "routing.rules: %s: provider fx requires fx.enabled", r)
+		}
+	}
+
+	check(len(c.Aggregate.Providers) > 0, "aggregate.providers must not be empty")
+	for _, p := range c.Aggregate.Providers {
+		check(slices.Contains(Providers, p) && p != client.AggregateName && p != client.FailoverName,
+			"aggregate.providers: unknown provider %q", p)
+		check(p != client.FXName || c.FX.Enabled, "aggregate.providers: provider fx requires fx.enabled")
+	}
+	check(c.Aggregate.MinSources > 0 && c.Aggregate.MinSources <= len(c.Aggregate.Providers),
+		"aggregate.min_sources must be between 1 and the number of aggregate.providers")
+	check(c.Aggregate.MaxDeviation >= 0, "aggregate.max_deviation must not be negative")
+	check(slices.Contains([]string{string(client.AggregateMedian), string(client.AggregateVWAP)}, c.Aggregate.Method),
+		"aggregate.method must be median or vwap, got %q", c.Aggregate.Method)
+
+	check(len(c.Failover.Providers) > 0, "failover.providers must not be empty")
+	for _, p := range c.Failover.Providers {
+		check(slices.Contains(Providers, p) && p != client.FailoverName, "failover.providers: unknown provider %q", p)
+		check(p != client.FXName || c.FX.Enabled, "failover.providers: provider fx requires fx.enabled")
+	}
+	check(c.Failover.Decay > 0 && c.Failover.Decay <= 1, "failover.decay must be in (0, 1]")
+	check(c.Failover.DemoteBelow >= 0 && c.Failover.DemoteBelow <= 1, "failover.demote_below must be in [0, 1]")
+	check(c.Failover.DemoteFor > 0, "failover.demote_for must be positive")
+
+	check(c.Service.Concurrency >= 0, "service.concurrency must not be negative")
+	check(c.Service.PairTimeout >= 0, "service.pair_timeout must not be negative")
+
+	check(len(c.Rates.DefaultPairs) > 0, "rates.default_pairs must not be empty")
+	errs = append(errs, c.Rates.DefaultPairs.validate("rates.default_pairs")...)
+	errs = append(errs, c.Poller.Pairs.validate("poller.pairs")...)
+
+	if c.Poller.Enabled {
+		check(c.Poller.Interval > 0, "poller.interval must be positive")
+		check(c.Poller.Jitter >= 0 && c.Poller.Jitter < 1, "poller.jitter must be in [0, 1)")
+		check(c.Poller.Timeout >= 0, "poller.timeout must not be negative")
+	}
+
+	if backend != "memory" && c.Poller.Enabled {
+		check(c.Leader.Key != "", "leader.key must be set")
+		check(c.Leader.TTL > 0, "leader.ttl must be positive")
+		// лидер слагает полномочия, не продлив lease за 4/5 TTL (leader.Config.Margin)
+		check(c.Leader.RenewInterval > 0 && c.Leader.RenewInterval < c.Leader.TTL-c.Leader.TTL/5,
+			"leader.renew_interval must be positive and less than 4/5 of leader.ttl")
+		check(c.Leader.RetryInterval > 0, "leader.retry_interval must be positive")
+	}
+	return errors.Join(errs...)
+}
+
+func (l PairList) validate(name string) []error {
+	var errs []error
+	for _, p := range l {
+		if !asset.ValidID(p.ID) || !asset.ValidID(p.VS) {
+			errs = append(errs, fmt.Errorf("%s: invalid pair %q", name, p.ID+"/"+p.VS))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,357 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"gopkg.in/yaml.v3"
+)
+
+// field связывает поле Config с переменной окружения и флагом.
+// Имя флага выводится из имени переменной: CACHE_L1_TTL → -cache-l1-ttl.
+type field struct {
+	env   string
+	usage string
+	value interface {
+		String() string
+		Set(string) error
+	}
+}
+
+func (f field) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(f.env, "_", "-"))
+}
+
+// fields перечисляет все поля cfg, задаваемые через окружение и флаги.
+func fields(cfg *Config) []field {
+	return []field{
+		{"HTTP_ADDR", "HTTP listen address", (*stringValue)(&cfg.HTTP.Addr)},
+		{"HTTP_REQUEST_TIMEOUT", "time budget of one price request", (*durationValue)(&cfg.HTTP.RequestTimeout)},
+		{"HTTP_SHUTDOWN_TIMEOUT", "graceful shutdown timeout", (*durationValue)(&cfg.HTTP.ShutdownTimeout)},
+		{"LOG_LEVEL", "log level: debug, info, warn, error", (*stringValue)(&cfg.Log.Level)},
+
+		{"CACHE_BACKEND", "cache implementation: redis, memory, tiered", (*stringValue)(&cfg.Cache.Backend)},
+		{"REDIS_ADDR", "Redis address host:port", (*stringValue)(&cfg.Cache.RedisAddr)},
+		{"CACHE_MAX_ENTRIES", "max entries of the in-memory cache or L1", (*intValue)(&cfg.Cache.MaxEntries)},
+		{"CACHE_L1_TTL", "L1 TTL of the tiered cache", (*durationValue)(&cfg.Cache.L1TTL)},
+		{"CACHE_FRESH_FOR", "how long a cached price is fresh", (*durationValue)(&cfg.Cache.FreshFor)},
+		{"CACHE_STALE_FOR", "how long a stale price is served while revalidating", (*durationValue)(&cfg.Cache.StaleFor)},
+		{"CACHE_MAX_STALE", "max age of a price served when the backend fails", (*durationValue)(&cfg.Cache.MaxStale)},
+		{"CACHE_NEGATIVE_TTL", "how long unknown pairs are cached, 0 disables", (*durationValue)(&cfg.Cache.NegativeTTL)},
+		{"CACHE_CLASS_FRESHNESS", "freshness windows per asset class, e.g. fiat=1h/1h/24h", &cfg.Cache.ClassFreshness},
+
+		{"COINGECKO_BASE_URL", "CoinGecko API base URL", (*stringValue)(&cfg.CoinGecko.BaseURL)},
+		{"COINGECKO_TIMEOUT", "timeout of one CoinGecko request attempt", (*durationValue)(&cfg.CoinGecko.Timeout)},
+		{"COINGECKO_RATE", "CoinGecko requests per second", (*floatValue)(&cfg.CoinGecko.Rate)},
+		{"COINGECKO_BURST", "CoinGecko rate limiter burst", (*intValue)(&cfg.CoinGecko.Burst)},
+
+		{"EXCHANGES_TIMEOUT", "timeout of one Binance, Kraken or Coinbase request attempt", (*durationValue)(&cfg.Exchanges.Timeout)},
+		{"BINANCE_BASE_URL", "Binance API base URL", (*stringValue)(&cfg.Exchanges.BinanceURL)},
+		{"KRAKEN_BASE_URL", "Kraken API base URL", (*stringValue)(&cfg.Exchanges.KrakenURL)},
+		{"COINBASE_BASE_URL", "Coinbase API base URL", (*stringValue)(&cfg.Exchanges.CoinbaseURL)},
+
+		{"FX_ENABLED", "serve fiat/fiat pairs from central bank rates", (*boolValue)(&cfg.FX.Enabled)},
+		{"FX_ECB_URL", "ECB daily rates XML URL", (*stringValue)(&cfg.FX.ECBURL)},
+		{"FX_CBR_URL", "Bank of Russia daily rates XML URL", (*stringValue)(&cfg.FX.CBRURL)},
+		{"FX_TIMEOUT", "timeout of one central bank request attempt", (*durationValue)(&cfg.FX.Timeout)},
+
+		{"ROUTING_RULES", "price provider rules, id/vs=provider,...;... (id/vs: id, crypto, stablecoin, fiat or *)", &cfg.Routing.Rules},
+
+		{"AGGREGATE_PROVIDERS", "providers queried by the aggregate provider, comma-separated", &cfg.Aggregate.Providers},
+		{"AGGREGATE_MIN_SOURCES", "agreeing prices required by the aggregate provider", (*intValue)(&cfg.Aggregate.MinSources)},
+		{"AGGREGATE_MAX_DEVIATION", "max deviation from the median before a price is an outlier, 0.02 is 2%", (*floatValue)(&cfg.Aggregate.MaxDeviation)},
+		{"AGGREGATE_METHOD", "aggregate price: median or vwap", (*stringValue)(&cfg.Aggregate.Method)},
+
+		{"FAILOVER_PROVIDERS", "providers of the failover chain by priority, comma-separated", &cfg.Failover.Providers},
+		{"FAILOVER_DECAY", "weight of the latest result in a provider health score, (0, 1]", (*floatValue)(&cfg.Failover.Decay)},
+		{"FAILOVER_DEMOTE_BELOW", "health score below which a provider is demoted", (*floatValue)(&cfg.Failover.DemoteBelow)},
+		{"FAILOVER_DEMOTE_FOR", "how long a provider stays demoted", (*durationValue)(&cfg.Failover.DemoteFor)},
+
+		{"SERVICE_CONCURRENCY", "max concurrent per-pair requests, 0 is unlimited", (*intValue)(&cfg.Service.Concurrency)},
+		{"SERVICE_PAIR_TIMEOUT", "timeout of one pair, not counting time queued behind the concurrency limit", (*durationValue)(&cfg.Service.PairTimeout)},
+
+		{"RATES_DEFAULT_PAIRS", "pairs served by /rates without parameters, id/vs,...", &cfg.Rates.DefaultPairs},
+
+		{"POLLER_ENABLED", "refresh watched pairs in background", (*boolValue)(&cfg.Poller.Enabled)},
+		{"POLLER_PAIRS", "pairs refreshed in background, id/vs,... (default: rates pairs)", &cfg.Poller.Pairs},
+		{"POLLER_INTERVAL", "background refresh interval", (*durationValue)(&cfg.Poller.Interval)},
+		{"POLLER_JITTER", "background refresh interval jitter, 0..1", (*floatValue)(&cfg.Poller.Jitter)},
+		{"POLLER_TIMEOUT", "timeout of one background refresh", (*durationValue)(&cfg.Poller.Timeout)},
+
+		{"LEADER_KEY", "Redis key of the poller leader lease", (*stringValue)(&cfg.Leader.Key)},
+		{"LEADER_TTL", "poller leader lease TTL", (*durationValue)(&cfg.Leader.TTL)},
+		{"LEADER_RENEW_INTERVAL", "poller leader lease renew interval", (*durationValue)(&cfg.Leader.RenewInterval)},
+		{"LEADER_RETRY_INTERVAL", "poller leader lease acquire retry interval", (*durationValue)(&cfg.Leader.RetryInterval)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) IsBoolFlag() bool { return true }
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+// StringList — список строк. В окружении и флагах задаётся через запятую, в YAML — списком.
+type StringList []string
+
+func (l *StringList) String() string { return strings.Join(*l, ",") }
+func (l *StringList) Set(s string) error {
+	var out StringList
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	*l = out
+	return nil
+}
+
+// PairList — список пар. В окружении и флагах задаётся как "bitcoin/usd,usd/rub",
+// в YAML — списком строк "id/vs".
+type PairList []price.Pair
+
+func (l *PairList) String() string {
+	parts := make([]string, len(*l))
+	for i, p := range *l {
+		parts[i] = p.ID + "/" + p.VS
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *PairList) Set(s string) error {
+	var out PairList
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		p, err := parsePair(part)
+		if err != nil {
+			return err
+		}
+		out = append(out, p)
+	}
+	*l = out
+	return nil
+}
+
+func (l *PairList) UnmarshalYAML(n *yaml.Node) error {
+	var items []string
+	if err := n.Decode(&items); err != nil {
+		return err
+	}
+	out := make(PairList, 0, len(items))
+	for _, item := range items {
+		p, err := parsePair(item)
+		if err != nil {
+			return err
+		}
+		out = append(out, p)
+	}
+	*l = out
+	return nil
+}
+
+func (l PairList) MarshalYAML() (any, error) {
+	items := make([]string, len(l))
+	for i, p := range l {
+		items[i] = p.ID + "/" + p.VS
+	}
+	return items, nil
+}
+
+// RouteList — правила маршрутизации провайдеров (client.Route). В окружении и флагах
+// задаётся как "fiat/fiat=fx;*/*=failover", в YAML — списком строк.
+type RouteList []client.Route
+
+func (l *RouteList) String() string {
+	parts := make([]string, len(*l))
+	for i, r := range *l {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ";")
+}
+
+func (l *RouteList) Set(s string) error {
+	var out RouteList
+	for _, part := range strings.Split(s, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		r, err := client.ParseRoute(part)
+		if err != nil {
+			return err
+		}
+		out = append(out, r)
+	}
+	*l = out
+	return nil
+}
+
+func (l *RouteList) UnmarshalYAML(n *yaml.Node) error {
+	var items []string
+	if err := n.Decode(&items); err != nil {
+		return err
+	}
+	out := make(RouteList, 0, len(items))
+	for _, item := range items {
+		r, err := client.ParseRoute(item)
+		if err != nil {
+			return err
+		}
+		out = append(out, r)
+	}
+	*l = out
+	return nil
+}
+
+func (l RouteList) MarshalYAML() (any, error) {
+	items := make([]string, len(l))
+	for i, r := range l {
+		items[i] = r.String()
+	}
+	return items, nil
+}
+
+// ClassFreshness — окна свежести пар класса актива.
+type ClassFreshness struct {
+	Class asset.Class
+	client.Freshness
+}
+
+func (cf ClassFreshness) String() string {
+	return fmt.Sprintf("%s=%s/%s/%s", cf.Class, cf.FreshFor, cf.StaleFor, cf.MaxStale)
+}
+
+// ClassFreshnessList — окна свежести по классам активов. Элемент записывается как
+// "class=fresh_for/stale_for/max_stale" (stale_for и max_stale можно опустить):
+// "fiat=1h/1h/24h". В окружении и флагах элементы разделяются запятой, в YAML — списком строк.
+type ClassFreshnessList []ClassFreshness
+
+func (l *ClassFreshnessList) String() string {
+	parts := make([]string, len(*l))
+	for i, cf := range *l {
+		parts[i] = cf.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *ClassFreshnessList) Set(s string) error {
+	var out ClassFreshnessList
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		cf, err := parseClassFreshness(part)
+		if err != nil {
+			return err
+		}
+		out = append(out, cf)
+	}
+	*l = out
+	return nil
+}
+
+func (l *ClassFreshnessList) UnmarshalYAML(n *yaml.Node) error {
+	var items []string
+	if err := n.Decode(&items); err != nil {
+		return err
+	}
+	out := make(ClassFreshnessList, 0, len(items))
+	for _, item := range items {
+		cf, err := parseClassFreshness(item)
+		if err != nil {
+			return err
+		}
+		out = append(out, cf)
+	}
+	*l = out
+	return nil
+}
+
+func (l ClassFreshnessList) MarshalYAML() (any, error) {
+	items := make([]string, len(l))
+	for i, cf := range l {
+		items[i] = cf.String()
+	}
+	return items, nil
+}
+
+func parseClassFreshness(s string) (ClassFreshness, error) {
+	class, windows, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "=")
+	parts := strings.Split(windows, "/")
+	if !ok || strings.TrimSpace(class) == "" || len(parts) > 3 {
+		return ClassFreshness{}, fmt.Errorf("invalid class freshness %q, want class=fresh_for/stale_for/max_stale", s)
+	}
+	cf := ClassFreshness{Class: asset.Class(strings.TrimSpace(class))}
+	dst := []*time.Duration{&cf.FreshFor, &cf.StaleFor, &cf.MaxStale}
+	for i, part := range parts {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return ClassFreshness{}, fmt.Errorf("invalid class freshness %q: %w", s, err)
+		}
+		*dst[i] = d
+	}
+	return cf, nil
+}
+
+func parsePair(s string) (price.Pair, error) {
+	id, vs, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "/")
+	if !ok || id == "" || vs == "" {
+		return price.Pair{}, fmt.Errorf("invalid pair %q, want id/vs", s)
+	}
+	return price.Pair{ID: id, VS: vs}, nil
+}
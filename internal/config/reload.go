@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"os"
+	"slices"
+	"time"
+)
+
+// staticField — поле, которое нельзя поменять без перезапуска: от него зависит
+// уже созданная инфраструктура (сервер, кэш, клиенты, выборы лидера).
+type staticField struct {
+	name    string
+	restore func(next, cur *Config) bool // возвращает cur-значение в next; true — значения различались
+}
+
+func static[T comparable](name string, get func(*Config) *T) staticField {
+	return staticField{name: name, restore: func(next, cur *Config) bool {
+		n, c := get(next), get(cur)
+		if *n == *c {
+			return false
+		}
+		*n = *c
+		return true
+	}}
+}
+
+var staticFields = []staticField{
+	static("http.addr", func(c *Config) *string { return &c.HTTP.Addr }),
+	static("http.shutdown_timeout", func(c *Config) *time.Duration { return &c.HTTP.ShutdownTimeout }),
+	static("cache.backend", func(c *Config) *string { return &c.Cache.Backend }),
+	static("cache.redis_addr", func(c *Config) *string { return &c.Cache.RedisAddr }),
+	static("cache.max_entries", func(c *Config) *int { return &c.Cache.MaxEntries }),
+	static("cache.l1_ttl", func(c *Config) *time.Duration { return &c.Cache.L1TTL }),
+	static("coingecko.base_url", func(c *Config) *string { return &c.CoinGecko.BaseURL }),
+	static("coingecko.timeout", func(c *Config) *time.Duration { return &c.CoinGecko.Timeout }),
+	static("exchanges.timeout", func(c *Config) *time.Duration { return &c.Exchanges.Timeout }),
+	static("exchanges.binance_url", func(c *Config) *string { return &c.Exchanges.BinanceURL }),
+	static("exchanges.kraken_url", func(c *Config) *string { return &c.Exchanges.KrakenURL }),
+	static("exchanges.coinbase_url", func(c *Config) *string { return &c.Exchanges.CoinbaseURL }),
+	static("fx.enabled", func(c *Config) *bool { return &c.FX.Enabled }),
+	static("fx.ecb_url", func(c *Config) *string { return &c.FX.ECBURL }),
+	static("fx.cbr_url", func(c *Config) *string { return &c.FX.CBRURL }),
+	static("fx.timeout", func(c *Config) *time.Duration { return &c.FX.Timeout }),
+	{name: "aggregate.providers", restore: func(next, cur *Config) bool {
+		if slices.Equal(next.Aggregate.Providers, cur.Aggregate.Providers) {
+			return false
+		}
+		next.Aggregate.Providers = cur.Aggregate.Providers
+		return true
+	}},
+	{name: "failover.providers", restore: func(next, cur *Config) bool {
+		if slices.Equal(next.Failover.Providers, cur.Failover.Providers) {
+			return false
+		}
+		next.Failover.Providers = cur.Failover.Providers
+		return true
+	}},
+	static("poller.enabled", func(c *Config) *bool { return &c.Poller.Enabled }),
+	static("leader.key", func(c *Config) *string { return &c.Leader.Key }),
+	static("leader.ttl", func(c *Config) *time.Duration { return &c.Leader.TTL }),
+	static("leader.renew_interval", func(c *Config) *time.Duration { return &c.Leader.RenewInterval }),
+	static("leader.retry_interval", func(c *Config) *time.Duration { return &c.Leader.RetryInterval }),
+}
+
+// Reloadable возвращает конфигурацию, которую можно применить к работающему
+// приложению: поля next, меняющиеся на лету (пары, TTL, лимиты, уровень логов),
+// и поля cur, требующие перезапуска. Второе значение — имена полей, изменения
+// которых были отброшены.
+func Reloadable(cur, next Config) (Config, []string) {
+	var rejected []string
+	for _, f := range staticFields {
+		if f.restore(&next, &cur) {
+			rejected = append(rejected, f.name)
+		}
+	}
+	return next, rejected
+}
+
+// WatchFile проверяет файл path раз в interval и вызывает onChange, когда меняется
+// его время изменения или размер. Ошибки stat пропускаются: файл могут заменять
+// атомарным переименованием. Возвращает nil после отмены ctx.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) error {
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+			last = info
+			onChange()
+		}
+	}
+}
@@ -2,48 +2,199 @@ package currency
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/boxdancer/go-currency-tracker/internal/price"
 	"golang.org/x/sync/errgroup"
 )
 
+// DefaultConcurrency — лимит одновременных запросов к клиенту по умолчанию.
+const DefaultConcurrency = 10
+
 type Service struct {
 	client price.PriceClient
+
+	// меняются на лету (см. SetConcurrency, SetPairTimeout)
+	concurrency atomic.Int64
+	pairTimeout atomic.Int64 // time.Duration
+}
+
+// Option настраивает Service.
+type Option func(*Service)
+
+// WithConcurrency ограничивает число одновременных запросов к клиенту в GetMany.
+// Для batch-клиента лимит передаётся вниз по цепочке (price.WithConcurrency) и
+// ограничивает запросы пар к провайдерам без batch-API. n <= 0 снимает ограничение.
+func WithConcurrency(n int) Option {
+	return func(s *Service) { s.SetConcurrency(n) }
+}
+
+// WithPairTimeout задаёт таймаут на получение одной пары, независимый от общего
+// дедлайна ctx; время в очереди из-за WithConcurrency в него не входит. Для batch-клиента
+// таймаут передаётся вниз по цепочке (price.WithPairTimeout) и действует на каждую пару,
+// которую провайдер без batch-API запрашивает отдельно. d <= 0 — без отдельного таймаута.
+func WithPairTimeout(d time.Duration) Option {
+	return func(s *Service) { s.SetPairTimeout(d) }
+}
+
+func NewService(c price.PriceClient, opts ...Option) *Service {
+	s := &Service{client: c}
+	s.SetConcurrency(DefaultConcurrency)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetConcurrency меняет лимит одновременных запросов на лету (см. WithConcurrency).
+// Уже идущие GetMany дорабатывают со старым лимитом.
+func (s *Service) SetConcurrency(n int) { s.concurrency.Store(int64(n)) }
+
+// SetPairTimeout меняет таймаут одной пары на лету (см. WithPairTimeout).
+func (s *Service) SetPairTimeout(d time.Duration) { s.pairTimeout.Store(int64(d)) }
+
+// CrossPairs строит декартово произведение ids × vs.
+// Пример: CrossPairs([bitcoin ethereum], [usd eur]) → 4 пары.
+func CrossPairs(ids, vs []string) []price.Pair {
+	return price.CrossPairs(ids, vs)
+}
+
+// PairError — ошибка получения цены конкретной пары.
+type PairError struct {
+	Pair price.Pair
+	Err  error
+}
+
+func (e *PairError) Error() string { return fmt.Sprintf("%s: %v", e.Pair, e.Err) }
+func (e *PairError) Unwrap() error { return e.Err }
+
+// Result — итог по одной паре: либо цена, либо ошибка (*PairError).
+type Result struct {
+	Pair  price.Pair
+	Price float64
+	Err   error
 }
 
-func NewService(c price.PriceClient) *Service {
-	return &Service{client: c}
+// Results — результаты GetMany в порядке запрошенных пар.
+type Results []Result
+
+// Prices возвращает успешные цены в виде {id: {vs: price}}.
+func (rs Results) Prices() map[string]map[string]float64 {
+	out := make(map[string]map[string]float64)
+	for _, r := range rs {
+		if r.Err != nil {
+			continue
+		}
+		if out[r.Pair.ID] == nil {
+			out[r.Pair.ID] = make(map[string]float64)
+		}
+		out[r.Pair.ID][r.Pair.VS] = r.Price
+	}
+	return out
+}
+
+// Failed возвращает результаты с ошибками.
+func (rs Results) Failed() Results {
+	var out Results
+	for _, r := range rs {
+		if r.Err != nil {
+			out = append(out, r)
+		}
+	}
+	return out
 }
 
-// GetMany получает цены для пар {id: vs} конкурентно.
-// Пример входа: {"bitcoin":"usd", "ethereum":"usd"}.
-func (s *Service) GetMany(ctx context.Context, pairs map[string]string) (map[string]map[string]float64, error) {
-	results := make(map[string]map[string]float64)
-	var mu sync.Mutex
+// Err объединяет ошибки всех пар (errors.Join) или возвращает nil, если ошибок нет.
+func (rs Results) Err() error {
+	var errs []error
+	for _, r := range rs {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	g, ctx := errgroup.WithContext(ctx)
+// GetMany получает цены для списка пар и возвращает результат по каждой паре.
+// Ошибка одной пары не отменяет остальные запросы.
+// Если клиент умеет batch-запросы (price.PairsClient, price.BatchQuoteClient,
+// price.BatchClient), все пары запрашиваются одним вызовом price.FetchPairs,
+// иначе — по паре конкурентно, не более WithConcurrency запросов одновременно.
+func (s *Service) GetMany(ctx context.Context, pairs []price.Pair) Results {
+	if isBatch(s.client) {
+		return s.getBatch(ctx, pairs)
+	}
 
-	for id, vs := range pairs {
-		id, vs := id, vs // захват значений цикла
+	results := make(Results, len(pairs))
+	var g errgroup.Group // без WithContext: ошибка пары не отменяет соседей
+	if n := int(s.concurrency.Load()); n > 0 {
+		g.SetLimit(n)
+	}
+	for i, p := range pairs {
 		g.Go(func() error {
-			price, err := s.client.GetPrice(ctx, id, vs)
-			if err != nil {
-				return fmt.Errorf("%s->%s: %w", id, vs, err)
+			results[i] = Result{Pair: p}
+			if err := ctx.Err(); err != nil {
+				// общий дедлайн истёк, пока пара ждала своей очереди
+				results[i].Err = &PairError{Pair: p, Err: err}
+				return nil
 			}
-			mu.Lock()
-			if results[id] == nil {
-				results[id] = make(map[string]float64)
+			pctx, cancel := s.pairContext(ctx)
+			defer cancel()
+			v, err := s.client.GetPrice(pctx, p.ID, p.VS)
+			if err != nil {
+				results[i].Err = &PairError{Pair: p, Err: err}
+				return nil
 			}
-			results[id][vs] = price
-			mu.Unlock()
+			results[i].Price = v
 			return nil
 		})
 	}
+	_ = g.Wait()
+	return results
+}
+
+// pairContext накладывает pairTimeout поверх ctx.
+func (s *Service) pairContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	d := time.Duration(s.pairTimeout.Load())
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func isBatch(c price.PriceClient) bool {
+	switch c.(type) {
+	case price.PairsClient, price.BatchQuoteClient, price.BatchClient:
+		return true
+	}
+	return false
+}
+
+// getBatch запрашивает все пары одним вызовом price.FetchPairs (клиенту с
+// price.PairsClient уходят ровно запрошенные пары, без расширения до ids × vs).
+// Лимит WithConcurrency и pairTimeout действуют на запросы пар внутри вызова.
+// Каждая пара без цены получает свою ошибку (price.ErrorFor); если вызов упал целиком —
+// его ошибку, а если ошибки у пары нет — price.ErrUnknownID или price.ErrUnknownVS.
+func (s *Service) getBatch(ctx context.Context, pairs []price.Pair) Results {
+	bctx := price.WithConcurrency(ctx, int(s.concurrency.Load()))
+	bctx = price.WithPairTimeout(bctx, time.Duration(s.pairTimeout.Load()))
+	quotes, err := price.FetchPairs(bctx, s.client, pairs)
 
-	if err := g.Wait(); err != nil {
-		return results, err // частичные данные уже в results
+	results := make(Results, len(pairs))
+	for i, p := range pairs {
+		results[i] = Result{Pair: p}
+		if q, ok := quotes[p]; ok {
+			results[i].Price = q.Price
+			continue
+		}
+		pairErr := price.ErrorFor(err, p)
+		if pairErr == nil {
+			pairErr = price.MissingPairError(p, quotes.HasID(p.ID))
+		}
+		results[i].Err = &PairError{Pair: p, Err: pairErr}
 	}
-	return results, nil
+	return results
 }
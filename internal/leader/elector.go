@@ -0,0 +1,229 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"go.uber.org/zap"
+)
+
+// Lock — распределённый lease с TTL (реализуется cache.RedisLock).
+type Lock interface {
+	// ID возвращает идентификатор владельца (этой реплики).
+	ID() string
+	// Acquire пытается захватить lease; false — он занят другим владельцем.
+	Acquire(ctx context.Context) (bool, error)
+	// Renew продлевает свой lease; false — lease потерян.
+	Renew(ctx context.Context) (bool, error)
+	// Release освобождает свой lease.
+	Release(ctx context.Context) error
+	// Holder возвращает ID текущего владельца или "", если lease свободен.
+	Holder(ctx context.Context) (string, error)
+}
+
+// Config задаёт тайминги выборов.
+type Config struct {
+	TTL           time.Duration // время жизни lease без продления
+	RenewInterval time.Duration // как часто лидер продлевает lease; должно быть заметно меньше TTL
+	RetryInterval time.Duration // как часто остальные реплики пытаются захватить lease
+	Margin        time.Duration // запас до истечения lease на задержки запросов и расхождение часов
+}
+
+// DefaultConfig — lease на 15 секунд с продлением каждые 5: если лидер умер,
+// другая реплика подхватит обновление не позже чем через ~20 секунд.
+// Не продлив lease за 12 секунд, лидер сам слагает полномочия.
+func DefaultConfig() Config {
+	return Config{
+		TTL:           15 * time.Second,
+		RenewInterval: 5 * time.Second,
+		RetryInterval: 5 * time.Second,
+		Margin:        3 * time.Second,
+	}
+}
+
+// Status — состояние реплики в выборах (отдаётся debug-эндпоинтом).
+type Status struct {
+	Name     string    `json:"name"`
+	Instance string    `json:"instance"`
+	Leader   bool      `json:"leader"`
+	Since    time.Time `json:"since,omitzero"`   // с какого момента реплика лидер
+	Holder   string    `json:"holder,omitempty"` // текущий владелец lease (только для ServeHTTP)
+}
+
+// Elector выбирает одного лидера среди реплик через Lock: лидер периодически
+// продлевает lease, остальные пытаются его захватить. Если лидер умер и перестал
+// продлевать lease, после TTL его место занимает другая реплика.
+type Elector struct {
+	name    string
+	lock    Lock
+	cfg     Config
+	metrics observability.Metrics
+	logger  *zap.SugaredLogger
+
+	mu        sync.Mutex
+	leader    bool
+	since     time.Time
+	lastRenew time.Time
+}
+
+// NewElector создаёт Elector. name используется в метриках и логах.
+// metrics может быть nil — тогда будет использован noop.
+func NewElector(name string, lock Lock, cfg Config, m observability.Metrics, logger *zap.SugaredLogger) *Elector {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	if cfg.RenewInterval <= 0 {
+		cfg.RenewInterval = cfg.TTL / 3
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = cfg.TTL / 3
+	}
+	if cfg.Margin <= 0 {
+		cfg.Margin = cfg.TTL / 5
+	}
+	e := &Elector{name: name, lock: lock, cfg: cfg, metrics: m, logger: logger}
+	m.SetLeader(name, false)
+	return e
+}
+
+// IsLeader сообщает, является ли реплика лидером сейчас. Лидерство заканчивается,
+// как только с последнего продления прошло TTL - Margin, даже если Renew ещё не вернулся:
+// к этому моменту lease мог истечь и достаться другой реплике.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	expired := e.leader && time.Since(e.lastRenew) >= e.cfg.TTL-e.cfg.Margin
+	if expired {
+		e.leader = false
+	}
+	leader := e.leader
+	e.mu.Unlock()
+
+	if expired {
+		e.logger.Warnw("leader lease not renewed in time", "name", e.name)
+		e.notify(false)
+	}
+	return leader
+}
+
+// Status возвращает состояние реплики.
+func (e *Elector) Status() Status {
+	leader := e.IsLeader()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := Status{Name: e.name, Instance: e.lock.ID(), Leader: leader}
+	if leader {
+		s.Since = e.since
+	}
+	return s
+}
+
+// Run участвует в выборах до отмены ctx, затем освобождает lease, если владеет им,
+// чтобы другая реплика подхватила лидерство без ожидания TTL. Возвращает nil.
+func (e *Elector) Run(ctx context.Context) error {
+	defer e.resign()
+	for {
+		e.tick(ctx)
+
+		wait := e.cfg.RetryInterval
+		if e.IsLeader() {
+			wait = e.cfg.RenewInterval
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// tick продлевает lease лидера или пытается захватить его.
+// Время продления берётся до запроса: так реплика не переоценит, сколько ещё живёт её lease.
+func (e *Elector) tick(ctx context.Context) {
+	start := time.Now()
+	if e.IsLeader() {
+		ok, err := e.lock.Renew(ctx)
+		switch {
+		case err == nil && ok:
+			e.mu.Lock()
+			e.lastRenew = start
+			e.mu.Unlock()
+		case err == nil:
+			e.logger.Warnw("leader lease lost", "name", e.name)
+			e.setLeader(false)
+		default:
+			// Redis может быть недоступен недолго: пока lease заведомо жив, остаёмся лидером;
+			// когда запас истечёт, лидерство снимет IsLeader.
+			if ctx.Err() == nil {
+				e.logger.Warnw("leader lease renew failed", "name", e.name, "error", err)
+			}
+		}
+		return
+	}
+
+	ok, err := e.lock.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.logger.Warnw("leader lease acquire failed", "name", e.name, "error", err)
+		}
+		return
+	}
+	if ok {
+		e.mu.Lock()
+		e.lastRenew = start
+		e.mu.Unlock()
+		e.setLeader(true)
+	}
+}
+
+// resign освобождает lease при остановке.
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := e.lock.Release(ctx); err != nil {
+		e.logger.Warnw("leader lease release failed", "name", e.name, "error", err)
+	}
+	e.setLeader(false)
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader = leader
+	if leader && changed {
+		e.since = time.Now()
+	}
+	e.mu.Unlock()
+
+	if changed {
+		e.notify(leader)
+	}
+}
+
+// notify публикует смену лидерства в метрики и лог.
+func (e *Elector) notify(leader bool) {
+	e.metrics.SetLeader(e.name, leader)
+	if leader {
+		e.logger.Infow("became leader", "name", e.name, "instance", e.lock.ID())
+	} else {
+		e.logger.Infow("lost leadership", "name", e.name, "instance", e.lock.ID())
+	}
+}
+
+// ServeHTTP отдаёт Status вместе с текущим владельцем lease (GET /debug/leader).
+func (e *Elector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := e.Status()
+	if holder, err := e.lock.Holder(r.Context()); err == nil {
+		s.Holder = holder
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s)
+}
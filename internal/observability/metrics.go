@@ -13,6 +13,50 @@ type Metrics interface {
 	// CacheHit / CacheMiss — счётчики попаданий/промахов кэша.
 	CacheHit()
 	CacheMiss()
+	// NegativeCacheHit отмечает запрос, обслуженный маркером неизвестной пары (negative caching).
+	NegativeCacheHit()
+	// StaleServed отмечает отданную устаревшую цену (reason: revalidate, error).
+	StaleServed(reason string)
+	// BackendCoalesced отмечает запрос, присоединившийся к уже идущему запросу к backend.
+	BackendCoalesced()
+	// BackendRetry отмечает повтор запроса к провайдеру (reason: rate_limited, unavailable).
+	BackendRetry(provider, reason string)
+	// SetBreakerState публикует состояние circuit breaker (0 closed, 1 open, 2 half-open).
+	SetBreakerState(name string, state int)
+	// ObserveRateLimitWait / RateLimitRejected — ожидание токена и отказы клиентского rate limiter.
+	ObserveRateLimitWait(name string, d time.Duration)
+	RateLimitRejected(name string)
+	// CacheEviction отмечает удалённую из кэша запись (reason: capacity, expired).
+	CacheEviction(cache, reason string)
+	// CacheTierLookup отмечает попадание/промах уровня многоуровневого кэша (tier: l1, l2).
+	CacheTierLookup(tier string, hit bool)
+	// SetInvalidationSubscribed публикует, подписана ли реплика на инвалидацию L1.
+	SetInvalidationSubscribed(subscribed bool)
+	// ObservePollerRefresh отмечает длительность и успех одного цикла фонового обновления.
+	ObservePollerRefresh(d time.Duration, success bool)
+	// SetPollerLag публикует, сколько прошло с последнего успешного фонового обновления
+	// (0 на репликах, которые не выполняют обновление, пока лидер другая реплика).
+	SetPollerLag(d time.Duration)
+	// SetLeader публикует, является ли реплика лидером в выборах name.
+	SetLeader(name string, leader bool)
+	// RouteResult отмечает пару, обработанную провайдером в роутере цен
+	// (result: served, fallback — провайдер не знает пару, error).
+	RouteResult(provider, result string)
+	// ObserveAggregateSpread отмечает разброс цен провайдеров в агрегаторе: (max-min)/медиана.
+	ObserveAggregateSpread(spread float64)
+	// AggregateOutlier отмечает цену провайдера, отброшенную агрегатором как выброс.
+	AggregateOutlier(provider string)
+	// AggregateNoQuorum отмечает пару, для которой агрегатор не набрал кворум.
+	AggregateNoQuorum()
+	// FailoverResult отмечает ответ провайдера в цепочке failover
+	// (result: served, failed — временная ошибка, unknown — не знает пару, error).
+	FailoverResult(provider, result string)
+	// SetProviderHealth публикует оценку здоровья провайдера в цепочке failover (0..1).
+	SetProviderHealth(provider string, score float64)
+	// ProviderDemoted отмечает понижение провайдера в конец цепочки failover.
+	ProviderDemoted(provider string)
+	// ConfigReload отмечает попытку перечитать конфигурацию на лету и её успех.
+	ConfigReload(success bool)
 }
 
 // Noop (для тестов)
@@ -20,9 +64,30 @@ type noopMetrics struct{}
 
 func NewNoopMetrics() Metrics { return &noopMetrics{} }
 
-func (n *noopMetrics) ObserveBackendCall(_ time.Duration, _ bool) {}
-func (n *noopMetrics) CacheHit()                                {}
-func (n *noopMetrics) CacheMiss()                               {}
+func (n *noopMetrics) ObserveBackendCall(_ time.Duration, _ bool)     {}
+func (n *noopMetrics) CacheHit()                                      {}
+func (n *noopMetrics) CacheMiss()                                     {}
+func (n *noopMetrics) NegativeCacheHit()                              {}
+func (n *noopMetrics) StaleServed(_ string)                           {}
+func (n *noopMetrics) BackendCoalesced()                              {}
+func (n *noopMetrics) BackendRetry(_, _ string)                       {}
+func (n *noopMetrics) SetBreakerState(_ string, _ int)                {}
+func (n *noopMetrics) ObserveRateLimitWait(_ string, _ time.Duration) {}
+func (n *noopMetrics) RateLimitRejected(_ string)                     {}
+func (n *noopMetrics) CacheEviction(_, _ string)                      {}
+func (n *noopMetrics) CacheTierLookup(_ string, _ bool)               {}
+func (n *noopMetrics) SetInvalidationSubscribed(_ bool)               {}
+func (n *noopMetrics) ObservePollerRefresh(_ time.Duration, _ bool)   {}
+func (n *noopMetrics) SetPollerLag(_ time.Duration)                   {}
+func (n *noopMetrics) SetLeader(_ string, _ bool)                     {}
+func (n *noopMetrics) RouteResult(_, _ string)                        {}
+func (n *noopMetrics) ObserveAggregateSpread(_ float64)               {}
+func (n *noopMetrics) AggregateOutlier(_ string)                      {}
+func (n *noopMetrics) AggregateNoQuorum()                             {}
+func (n *noopMetrics) FailoverResult(_, _ string)                     {}
+func (n *noopMetrics) SetProviderHealth(_ string, _ float64)          {}
+func (n *noopMetrics) ProviderDemoted(_ string)                       {}
+func (n *noopMetrics) ConfigReload(_ bool)                            {}
 
 // Prometheus реализация
 type prometheusMetrics struct {
@@ -30,6 +95,28 @@ type prometheusMetrics struct {
 	backendErrors  prometheus.Counter
 	cacheHits      prometheus.Counter
 	cacheMisses    prometheus.Counter
+	negativeHits   prometheus.Counter
+	staleServed    *prometheus.CounterVec
+	coalesced      prometheus.Counter
+	backendRetries *prometheus.CounterVec
+	breakerState   *prometheus.GaugeVec
+	limiterWait    *prometheus.HistogramVec
+	limiterReject  *prometheus.CounterVec
+	cacheEvicted   *prometheus.CounterVec
+	cacheTier      *prometheus.CounterVec
+	invalidation   prometheus.Gauge
+	pollerRefresh  *prometheus.HistogramVec
+	pollerLag      prometheus.Gauge
+	leader         *prometheus.GaugeVec
+	routeResults   *prometheus.CounterVec
+	aggSpread      prometheus.Histogram
+	aggOutliers    *prometheus.CounterVec
+	aggNoQuorum    prometheus.Counter
+	failover       *prometheus.CounterVec
+	health         *prometheus.GaugeVec
+	demotions      *prometheus.CounterVec
+	configReloads  *prometheus.CounterVec
+	configLast     prometheus.Gauge
 }
 
 // NewPrometheusMetrics регистрирует и возвращает реализацию Metrics.
@@ -54,10 +141,115 @@ func NewPrometheusMetrics() Metrics {
 			Name: "cached_client_cache_misses_total",
 			Help: "Number of cache misses in CachedPriceClient",
 		}),
+		negativeHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cached_client_negative_hits_total",
+			Help: "Number of requests for unknown pairs answered from the negative cache",
+		}),
+		staleServed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cached_client_stale_served_total",
+			Help: "Number of stale prices served by CachedPriceClient, labeled by reason (revalidate, error)",
+		}, []string{"reason"}),
+		coalesced: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cached_client_coalesced_total",
+			Help: "Number of backend fetches joined to an identical in-flight fetch",
+		}),
+		backendRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "client_backend_retries_total",
+			Help: "Number of retried upstream requests, labeled by provider and reason",
+		}, []string{"provider", "reason"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state: 0 closed, 1 open, 2 half-open",
+		}, []string{"name"}),
+		limiterWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "rate_limiter_wait_seconds",
+			Help:    "Time spent waiting for a rate limiter token, labeled by limiter name",
+			Buckets: []float64{0, .01, .05, .1, .25, .5, 1, 2, 5, 10},
+		}, []string{"name"}),
+		limiterReject: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rate_limiter_rejected_total",
+			Help: "Number of calls rejected by the client-side rate limiter",
+		}, []string{"name"}),
+		cacheEvicted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Number of entries removed from an in-process cache, labeled by cache and reason (capacity, expired)",
+		}, []string{"cache", "reason"}),
+		cacheTier: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_tier_lookups_total",
+			Help: "Number of lookups per tier of the tiered cache, labeled by tier (l1, l2) and result (hit, miss)",
+		}, []string{"tier", "result"}),
+		invalidation: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_invalidation_subscribed",
+			Help: "Whether this replica is subscribed to L1 invalidation messages: 1 subscribed, 0 not",
+		}),
+		pollerRefresh: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "poller_refresh_duration_seconds",
+			Help:    "Duration of background watchlist refreshes in seconds, labeled by success",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"success"}),
+		pollerLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "poller_refresh_lag_seconds",
+			Help: "Seconds since the last successful background watchlist refresh; 0 on replicas that are not the poller leader",
+		}),
+		leader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "leader_election_is_leader",
+			Help: "Whether this replica holds the leader lease: 1 leader, 0 follower",
+		}, []string{"name"}),
+		routeResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "price_router_results_total",
+			Help: "Number of pairs handled by each provider of the price router, labeled by provider and result (served, fallback, error)",
+		}, []string{"provider", "result"}),
+		aggSpread: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "price_aggregate_spread_ratio",
+			Help:    "Disagreement of provider prices in the aggregator: (max - min) / median",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .02, .05, .1, .25},
+		}),
+		aggOutliers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "price_aggregate_outliers_total",
+			Help: "Number of provider prices rejected by the aggregator as outliers, labeled by provider",
+		}, []string{"provider"}),
+		aggNoQuorum: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "price_aggregate_quorum_failures_total",
+			Help: "Number of pairs the aggregator failed to price for lack of agreeing sources",
+		}),
+		failover: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "price_failover_results_total",
+			Help: "Number of provider answers in the failover chain, labeled by provider and result (served, failed, unknown, error)",
+		}, []string{"provider", "result"}),
+		health: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "price_provider_health",
+			Help: "Health score of a provider in the failover chain: 1 all requests succeed, 0 all fail",
+		}, []string{"provider"}),
+		demotions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "price_provider_demotions_total",
+			Help: "Number of times a provider was demoted to the end of the failover chain",
+		}, []string{"provider"}),
+		configReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "Number of runtime config reloads, labeled by result (success, failure)",
+		}, []string{"result"}),
+		configLast: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "config_last_reload_successful",
+			Help: "Whether the last runtime config reload succeeded: 1 success, 0 failure",
+		}),
 	}
 
 	// Регистрируем метрики (паника, если зарегистрировать дважды).
-	prometheus.MustRegister(m.backendLatency, m.backendErrors, m.cacheHits, m.cacheMisses)
+	prometheus.MustRegister(
+		m.backendLatency, m.backendErrors, m.cacheHits, m.cacheMisses,
+		m.negativeHits,
+		m.staleServed, m.coalesced,
+		m.backendRetries,
+		m.breakerState,
+		m.limiterWait, m.limiterReject,
+		m.cacheEvicted, m.cacheTier, m.invalidation,
+		m.pollerRefresh, m.pollerLag,
+		m.leader,
+		m.routeResults,
+		m.aggSpread, m.aggOutliers, m.aggNoQuorum,
+		m.failover, m.health, m.demotions,
+		m.configReloads, m.configLast,
+	)
 
 	return m
 }
@@ -78,3 +270,108 @@ func (m *prometheusMetrics) CacheHit() {
 func (m *prometheusMetrics) CacheMiss() {
 	m.cacheMisses.Inc()
 }
+
+func (m *prometheusMetrics) NegativeCacheHit() {
+	m.negativeHits.Inc()
+}
+
+func (m *prometheusMetrics) StaleServed(reason string) {
+	m.staleServed.WithLabelValues(reason).Inc()
+}
+
+func (m *prometheusMetrics) BackendCoalesced() {
+	m.coalesced.Inc()
+}
+
+func (m *prometheusMetrics) BackendRetry(provider, reason string) {
+	m.backendRetries.WithLabelValues(provider, reason).Inc()
+}
+
+func (m *prometheusMetrics) SetBreakerState(name string, state int) {
+	m.breakerState.WithLabelValues(name).Set(float64(state))
+}
+
+func (m *prometheusMetrics) ObserveRateLimitWait(name string, d time.Duration) {
+	m.limiterWait.WithLabelValues(name).Observe(d.Seconds())
+}
+
+func (m *prometheusMetrics) RateLimitRejected(name string) {
+	m.limiterReject.WithLabelValues(name).Inc()
+}
+
+func (m *prometheusMetrics) CacheEviction(cache, reason string) {
+	m.cacheEvicted.WithLabelValues(cache, reason).Inc()
+}
+
+func (m *prometheusMetrics) CacheTierLookup(tier string, hit bool) {
+	result := "hit"
+	if !hit {
+		result = "miss"
+	}
+	m.cacheTier.WithLabelValues(tier, result).Inc()
+}
+
+func (m *prometheusMetrics) SetInvalidationSubscribed(subscribed bool) {
+	v := 0.0
+	if subscribed {
+		v = 1
+	}
+	m.invalidation.Set(v)
+}
+
+func (m *prometheusMetrics) ObservePollerRefresh(d time.Duration, success bool) {
+	label := "true"
+	if !success {
+		label = "false"
+	}
+	m.pollerRefresh.WithLabelValues(label).Observe(d.Seconds())
+}
+
+func (m *prometheusMetrics) SetPollerLag(d time.Duration) {
+	m.pollerLag.Set(d.Seconds())
+}
+
+func (m *prometheusMetrics) SetLeader(name string, leader bool) {
+	v := 0.0
+	if leader {
+		v = 1
+	}
+	m.leader.WithLabelValues(name).Set(v)
+}
+
+func (m *prometheusMetrics) RouteResult(provider, result string) {
+	m.routeResults.WithLabelValues(provider, result).Inc()
+}
+
+func (m *prometheusMetrics) ObserveAggregateSpread(spread float64) {
+	m.aggSpread.Observe(spread)
+}
+
+func (m *prometheusMetrics) AggregateOutlier(provider string) {
+	m.aggOutliers.WithLabelValues(provider).Inc()
+}
+
+func (m *prometheusMetrics) AggregateNoQuorum() {
+	m.aggNoQuorum.Inc()
+}
+
+func (m *prometheusMetrics) FailoverResult(provider, result string) {
+	m.failover.WithLabelValues(provider, result).Inc()
+}
+
+func (m *prometheusMetrics) SetProviderHealth(provider string, score float64) {
+	m.health.WithLabelValues(provider).Set(score)
+}
+
+func (m *prometheusMetrics) ProviderDemoted(provider string) {
+	m.demotions.WithLabelValues(provider).Inc()
+}
+
+func (m *prometheusMetrics) ConfigReload(success bool) {
+	result, v := "success", 1.0
+	if !success {
+		result, v = "failure", 0
+	}
+	m.configReloads.WithLabelValues(result).Inc()
+	m.configLast.Set(v)
+}
@@ -0,0 +1,173 @@
+package poller
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"go.uber.org/zap"
+)
+
+// Refresher обновляет цены пар в кэше в обход самого кэша
+// (реализуется client.CachedPriceClient).
+type Refresher interface {
+	Refresh(ctx context.Context, pairs []price.Pair) error
+}
+
+// Leader сообщает, должна ли эта реплика выполнять фоновое обновление
+// (реализуется leader.Elector).
+type Leader interface {
+	IsLeader() bool
+}
+
+// Config задаёт фоновое обновление watchlist.
+type Config struct {
+	Pairs    []price.Pair  // пары, которые держатся тёплыми в кэше
+	Interval time.Duration // период обновления
+	Jitter   float64       // доля случайного разброса периода, 0..1
+	Timeout  time.Duration // ограничение одного цикла; <= 0 — только ctx
+}
+
+// DefaultConfig — обновление раз в 30 секунд: заметно чаще, чем истекает свежесть цены (минута).
+func DefaultConfig(pairs []price.Pair) Config {
+	return Config{
+		Pairs:    pairs,
+		Interval: 30 * time.Second,
+		Jitter:   0.1,
+		Timeout:  10 * time.Second,
+	}
+}
+
+// Poller периодически обновляет цены Config.Pairs через Refresher, чтобы запросы
+// пользователей почти всегда попадали в кэш. Первый цикл выполняется сразу при старте.
+// Config можно заменить на лету через SetConfig.
+type Poller struct {
+	refresher Refresher
+	metrics   observability.Metrics
+	logger    *zap.SugaredLogger
+	leader    Leader
+
+	mu  sync.Mutex
+	cfg Config
+
+	lastSuccess time.Time
+}
+
+// Option настраивает Poller.
+type Option func(*Poller)
+
+// WithLeader включает обновление только на реплике-лидере: остальные реплики
+// пропускают циклы, пока l.IsLeader() == false, и публикуют нулевое отставание
+// (за него отвечает лидер). Став лидером, реплика считает отставание заново.
+func WithLeader(l Leader) Option {
+	return func(p *Poller) { p.leader = l }
+}
+
+// New создаёт Poller. metrics может быть nil — тогда будет использован noop.
+func New(r Refresher, cfg Config, m observability.Metrics, logger *zap.SugaredLogger, opts ...Option) *Poller {
+	if m == nil {
+		m = observability.NewNoopMetrics()
+	}
+	p := &Poller{refresher: r, cfg: cfg, metrics: m, logger: logger}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// SetConfig заменяет конфигурацию на лету: новые пары и таймаут применяются
+// со следующего цикла, новый интервал — со следующей паузы.
+func (p *Poller) SetConfig(cfg Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg = cfg
+}
+
+func (p *Poller) config() Config {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg
+}
+
+// Run обновляет watchlist до отмены ctx. Текущий цикл при отмене прерывается.
+// Возвращает nil после остановки.
+func (p *Poller) Run(ctx context.Context) error {
+	cfg := p.config()
+	if len(cfg.Pairs) == 0 || cfg.Interval <= 0 {
+		p.logger.Infow("poller disabled", "pairs", len(cfg.Pairs), "interval", cfg.Interval)
+		return nil
+	}
+	p.logger.Infow("poller started", "pairs", len(cfg.Pairs), "interval", cfg.Interval)
+
+	started := time.Now()
+	leading := p.leader == nil
+	for {
+		cfg = p.config()
+		if p.leader == nil || p.leader.IsLeader() {
+			if !leading {
+				// обновления прошлого срока лидерства не в счёт: отставание — с момента избрания
+				leading, started, p.lastSuccess = true, time.Now(), time.Time{}
+			}
+			p.refresh(ctx, cfg)
+			p.metrics.SetPollerLag(p.lag(started))
+		} else {
+			leading = false
+			p.metrics.SetPollerLag(0)
+		}
+
+		timer := time.NewTimer(cfg.nextDelay())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.logger.Info("poller stopped")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh выполняет один цикл обновления.
+func (p *Poller) refresh(ctx context.Context, cfg Config) {
+	if len(cfg.Pairs) == 0 {
+		return
+	}
+	rctx := ctx
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		rctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := p.refresher.Refresh(rctx, cfg.Pairs)
+	p.metrics.ObservePollerRefresh(time.Since(start), err == nil)
+	if err != nil {
+		if ctx.Err() == nil { // при остановке приложения ошибка ожидаема
+			p.logger.Warnw("poller refresh failed", "pairs", len(cfg.Pairs), "error", err)
+		}
+		return
+	}
+	p.lastSuccess = time.Now()
+}
+
+// lag — время с последнего успешного цикла (или со старта, если успешных ещё не было).
+func (p *Poller) lag(started time.Time) time.Duration {
+	if p.lastSuccess.IsZero() {
+		return time.Since(started)
+	}
+	return time.Since(p.lastSuccess)
+}
+
+// nextDelay возвращает Interval со случайным разбросом ±Jitter, чтобы реплики
+// и соседние циклы не били в провайдера одновременно.
+func (c Config) nextDelay() time.Duration {
+	d := c.Interval
+	if c.Jitter > 0 {
+		delta := float64(d) * c.Jitter
+		d += time.Duration(delta * (2*rand.Float64() - 1))
+	}
+	return d
+}
@@ -0,0 +1,79 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Ошибки клиентов цен. Проверяются через errors.Is / errors.As.
+var (
+	// ErrUnknownID — провайдер не знает актив id.
+	ErrUnknownID = errors.New("unknown id")
+	// ErrUnknownVS — провайдер не знает валюту vs для актива.
+	ErrUnknownVS = errors.New("unknown vs currency")
+	// ErrRateLimited — провайдер ограничил частоту запросов (см. RateLimitError).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUpstreamUnavailable — провайдер недоступен: сетевая ошибка или 5xx.
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	// ErrBadPayload — ответ провайдера не удалось разобрать.
+	ErrBadPayload = errors.New("bad upstream payload")
+	// ErrCircuitOpen — запрос отклонён без обращения к провайдеру: circuit breaker разомкнут.
+	// Частный случай ErrUpstreamUnavailable.
+	ErrCircuitOpen = fmt.Errorf("%w: circuit open", ErrUpstreamUnavailable)
+	// ErrNoQuorum — агрегатор не набрал нужного числа согласованных цен (см. QuorumError).
+	// Частный случай ErrUpstreamUnavailable.
+	ErrNoQuorum = fmt.Errorf("%w: no quorum", ErrUpstreamUnavailable)
+)
+
+// RateLimitError — ответ 429. RetryAfter — пауза, которую просит провайдер (0, если не указана).
+// errors.Is(err, ErrRateLimited) == true.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: retry after %s", ErrRateLimited, e.RetryAfter)
+	}
+	return ErrRateLimited.Error()
+}
+
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+// StatusError — неожиданный HTTP-статус от провайдера.
+// Для 5xx errors.Is(err, ErrUpstreamUnavailable) == true.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string { return "unexpected status: " + e.Status }
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrUpstreamUnavailable && e.StatusCode >= http.StatusInternalServerError
+}
+
+// QuorumError — после отбраковки выбросов у агрегатора осталось Got цен пары из
+// Need необходимых. Err — ошибки провайдеров, не давших цену (nil, если все ответили).
+// errors.Is(err, ErrNoQuorum) == true; ошибки провайдеров через errors.Is не видны,
+// чтобы отказ одного из них не выдавался за ответ всего агрегатора.
+type QuorumError struct {
+	Pair Pair
+	Got  int
+	Need int
+	Err  error
+}
+
+func (e *QuorumError) Error() string {
+	msg := fmt.Sprintf("%s: %s: %d of %d sources", ErrNoQuorum, e.Pair, e.Got, e.Need)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *QuorumError) Is(target error) bool {
+	return target == ErrNoQuorum || target == ErrUpstreamUnavailable
+}
@@ -1,9 +1,295 @@
 package price
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
 
 // PriceClient описывает минимальный контракт клиента цен.
 // Интерфейс находится в отдельном пакете, чтобы избежать циклических импортов.
 type PriceClient interface {
 	GetPrice(ctx context.Context, id, vs string) (float64, error)
 }
+
+// Pair — пара «актив id в валюте vs».
+type Pair struct {
+	ID string
+	VS string
+}
+
+func (p Pair) String() string { return p.ID + "->" + p.VS }
+
+// SplitPairs возвращает уникальные ids и vs из списка пар (в порядке появления).
+func SplitPairs(pairs []Pair) (ids, vs []string) {
+	seenID, seenVS := make(map[string]bool), make(map[string]bool)
+	for _, p := range pairs {
+		if !seenID[p.ID] {
+			seenID[p.ID] = true
+			ids = append(ids, p.ID)
+		}
+		if !seenVS[p.VS] {
+			seenVS[p.VS] = true
+			vs = append(vs, p.VS)
+		}
+	}
+	return ids, vs
+}
+
+// CrossPairs строит декартово произведение ids × vs.
+func CrossPairs(ids, vs []string) []Pair {
+	pairs := make([]Pair, 0, len(ids)*len(vs))
+	for _, id := range ids {
+		for _, v := range vs {
+			pairs = append(pairs, Pair{ID: id, VS: v})
+		}
+	}
+	return pairs
+}
+
+// Quote — цена пары вместе с метаданными о том, откуда и когда она получена.
+type Quote struct {
+	ID                string
+	VS                string
+	Price             float64
+	Source            string    // имя провайдера (например, "coingecko"); пусто, если неизвестно
+	FetchedAt         time.Time // момент получения цены от провайдера; zero, если неизвестен
+	UpstreamUpdatedAt time.Time // момент обновления цены у самого провайдера; zero, если он не сообщает
+	Volume            float64   // объём торгов за 24 часа в единицах id; 0, если провайдер не сообщает
+	Sources           int       // сколько провайдеров сошлись в цене (client.Aggregator); 0 — цена одного провайдера
+	Cached            bool      // цена отдана из кэша
+	Stale             bool      // цена устарела: отдана из кэша за пределами окна свежести
+}
+
+// Age возвращает возраст цены относительно now (0, если FetchedAt неизвестен).
+func (q Quote) Age(now time.Time) time.Duration {
+	if q.FetchedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(q.FetchedAt)
+}
+
+// Quotes — результат batch-запроса котировок.
+type Quotes map[Pair]Quote
+
+// Prices возвращает цены в виде {id: {vs: price}}.
+func (qs Quotes) Prices() map[string]map[string]float64 {
+	out := make(map[string]map[string]float64)
+	for p, q := range qs {
+		if out[p.ID] == nil {
+			out[p.ID] = make(map[string]float64)
+		}
+		out[p.ID][p.VS] = q.Price
+	}
+	return out
+}
+
+// HasID сообщает, есть ли в результате хотя бы одна котировка для id.
+func (qs Quotes) HasID(id string) bool {
+	for p := range qs {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// QuoteClient — опциональное расширение PriceClient, возвращающее цену с метаданными.
+type QuoteClient interface {
+	GetQuote(ctx context.Context, id, vs string) (Quote, error)
+}
+
+// BatchClient — опциональное расширение PriceClient для бэкендов, умеющих
+// получать цены многих пар за один вызов (например, CoinGecko /simple/price).
+// Результат имеет вид {id: {vs: price}}; пары, для которых цены нет, отсутствуют.
+type BatchClient interface {
+	GetPrices(ctx context.Context, ids, vs []string) (map[string]map[string]float64, error)
+}
+
+// BatchQuoteClient — batch-вариант QuoteClient: котировки пар ids × vs с метаданными
+// за один вызов. Пары, для которых цены нет, в результате отсутствуют.
+type BatchQuoteClient interface {
+	GetQuotes(ctx context.Context, ids, vs []string) (Quotes, error)
+}
+
+// PairsClient — опциональное расширение PriceClient для клиентов, которые умеют
+// запрашивать произвольный список пар, не расширяя его до ids × vs (кэш, маршрутизация,
+// цепочки провайдеров). Пары, для которых цены нет, в результате отсутствуют;
+// ошибки отдельных пар возвращаются как PairErrors (см. ErrorFor).
+type PairsClient interface {
+	GetPairs(ctx context.Context, pairs []Pair) (Quotes, error)
+}
+
+// PairErrors — ошибки отдельных пар batch-вызова, когда пары упали по разным причинам.
+// Пара без цены и без ошибки в PairErrors неизвестна backend (см. MissingPairError).
+type PairErrors map[Pair]error
+
+func (e PairErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for p, err := range e {
+		lines = append(lines, fmt.Sprintf("%s: %v", p, err))
+	}
+	slices.Sort(lines)
+	return strings.Join(lines, "\n")
+}
+
+func (e PairErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Err возвращает e как error или nil, если ошибок нет.
+func (e PairErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ErrorFor возвращает ошибку пары p по ошибке err batch-вызова: ошибку p из
+// PairErrors (nil, если у p её нет) или сам err, если вызов упал целиком.
+func ErrorFor(err error, p Pair) error {
+	var pe PairErrors
+	if errors.As(err, &pe) {
+		return pe[p]
+	}
+	return err
+}
+
+// MissingPairError объясняет, почему пары p нет в успешном batch-ответе:
+// ErrUnknownID, если в ответе нет самого id (idFound=false), иначе ErrUnknownVS.
+func MissingPairError(p Pair, idFound bool) error {
+	if !idFound {
+		return fmt.Errorf("%w: no id %q in response", ErrUnknownID, p.ID)
+	}
+	return fmt.Errorf("%w: no vs %q for id %q in response", ErrUnknownVS, p.VS, p.ID)
+}
+
+// FetchQuote запрашивает Quote у c. Если c не реализует QuoteClient,
+// цена берётся через GetPrice, а FetchedAt выставляется в текущее время.
+func FetchQuote(ctx context.Context, c PriceClient, id, vs string) (Quote, error) {
+	if qc, ok := c.(QuoteClient); ok {
+		return qc.GetQuote(ctx, id, vs)
+	}
+	p, err := c.GetPrice(ctx, id, vs)
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{ID: id, VS: vs, Price: p, FetchedAt: time.Now()}, nil
+}
+
+// FetchQuotes запрашивает котировки пар ids × vs у c: через BatchQuoteClient или
+// BatchClient одним вызовом, иначе — по паре конкурентно через FetchQuote.
+// Пары с ErrUnknownID/ErrUnknownVS в результат не попадают; прочие ошибки
+// возвращаются вместе с частичным результатом (по парам — как PairErrors).
+func FetchQuotes(ctx context.Context, c PriceClient, ids, vs []string) (Quotes, error) {
+	if bc, ok := c.(BatchQuoteClient); ok {
+		return bc.GetQuotes(ctx, ids, vs)
+	}
+	if bc, ok := c.(BatchClient); ok {
+		data, err := bc.GetPrices(ctx, ids, vs)
+		now := time.Now()
+		out := make(Quotes)
+		for id, byVS := range data {
+			for v, p := range byVS {
+				out[Pair{ID: id, VS: v}] = Quote{ID: id, VS: v, Price: p, FetchedAt: now}
+			}
+		}
+		return out, err
+	}
+	if pc, ok := c.(PairsClient); ok {
+		return pc.GetPairs(ctx, CrossPairs(ids, vs))
+	}
+	return fetchEach(ctx, c, CrossPairs(ids, vs))
+}
+
+// FetchPairs — то же, что FetchQuotes, но для произвольного списка пар. PairsClient
+// получает ровно эти пары; batch-клиенту уходит один вызов на ids × vs этих пар
+// (лишние пары отбрасываются), остальным — запросы только самих пар.
+func FetchPairs(ctx context.Context, c PriceClient, pairs []Pair) (Quotes, error) {
+	if pc, ok := c.(PairsClient); ok {
+		return pc.GetPairs(ctx, pairs)
+	}
+	_, batchQuotes := c.(BatchQuoteClient)
+	_, batch := c.(BatchClient)
+	if !batchQuotes && !batch {
+		return fetchEach(ctx, c, pairs)
+	}
+	ids, vs := SplitPairs(pairs)
+	quotes, err := FetchQuotes(ctx, c, ids, vs)
+	out := make(Quotes, len(pairs))
+	for _, p := range pairs {
+		if q, ok := quotes[p]; ok {
+			out[p] = q
+		}
+	}
+	return out, err
+}
+
+type (
+	concurrencyKey struct{}
+	pairTimeoutKey struct{}
+)
+
+// WithConcurrency ограничивает число одновременных запросов пар, которые FetchQuotes
+// и FetchPairs делают по одной (для клиентов без batch-API), во всех вызовах с ctx —
+// в том числе внутри цепочки клиентов (маршрутизация, failover, кэш). n <= 0 — без ограничения.
+func WithConcurrency(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, concurrencyKey{}, n)
+}
+
+// WithPairTimeout задаёт таймаут запроса одной пары, которые FetchQuotes и FetchPairs
+// делают по одной, во всех вызовах с ctx. Отсчёт идёт с момента, когда пара дождалась
+// своей очереди (WithConcurrency). Batch-запрос провайдера им не ограничивается.
+// d <= 0 — без отдельного таймаута.
+func WithPairTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, pairTimeoutKey{}, d)
+}
+
+// pairContext накладывает таймаут WithPairTimeout поверх ctx.
+func pairContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d, ok := ctx.Value(pairTimeoutKey{}).(time.Duration); ok && d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return ctx, func() {}
+}
+
+// fetchEach запрашивает пары конкурентно через FetchQuote, не более WithConcurrency
+// одновременно и каждую не дольше WithPairTimeout. Ошибка каждой пары остаётся
+// её собственной (PairErrors).
+func fetchEach(ctx context.Context, c PriceClient, pairs []Pair) (Quotes, error) {
+	results := make(Quotes)
+	errs := make(PairErrors)
+	var mu sync.Mutex
+	var g errgroup.Group
+	if n, ok := ctx.Value(concurrencyKey{}).(int); ok && n > 0 {
+		g.SetLimit(n)
+	}
+	for _, p := range pairs {
+		g.Go(func() error {
+			pctx, cancel := pairContext(ctx)
+			q, err := FetchQuote(pctx, c, p.ID, p.VS)
+			cancel()
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				results[p] = q
+			case !errors.Is(err, ErrUnknownID) && !errors.Is(err, ErrUnknownVS):
+				errs[p] = err
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results, errs.Err()
+}
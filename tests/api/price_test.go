@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/api"
+	"github.com/boxdancer/go-currency-tracker/internal/currency"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+	"go.uber.org/zap"
+)
+
+func newMux(fake *testutil.FakePriceClient) *http.ServeMux {
+	h := api.NewHandlers(fake, currency.NewService(fake), zap.NewNop().Sugar())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/price/{id}/{vs}", h.Price)
+	mux.HandleFunc("GET /rates", h.Rates)
+	return mux
+}
+
+func TestHandlers_Price(t *testing.T) {
+	fake := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}: 100.5,
+			{ID: "usd", VS: "rub"}:     90.65,
+		},
+		Errors: map[testutil.Key]error{
+			{ID: "ethereum", VS: "usd"}: testutil.Err("secret upstream details"),
+			{ID: "solana", VS: "usd"}:   fmt.Errorf("%w: no id in response", price.ErrUnknownID),
+			{ID: "ripple", VS: "usd"}:   &price.RateLimitError{RetryAfter: 30 * time.Second},
+			{ID: "dogecoin", VS: "usd"}: &price.RateLimitError{RetryAfter: 300 * time.Millisecond},
+			{ID: "cardano", VS: "usd"}:  fmt.Errorf("%w: connection refused", price.ErrUpstreamUnavailable),
+		},
+	}
+	mux := newMux(fake)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantPrice  float64
+		wantRetry  string
+	}{
+		{name: "success", path: "/v1/price/bitcoin/usd", wantStatus: http.StatusOK, wantPrice: 100.5},
+		{name: "fiat pair", path: "/v1/price/usd/rub", wantStatus: http.StatusOK, wantPrice: 90.65},
+		{name: "invalid id", path: "/v1/price/BIT$COIN/usd", wantStatus: http.StatusBadRequest},
+		{name: "unknown id", path: "/v1/price/notacoin/usd", wantStatus: http.StatusNotFound},
+		{name: "unknown vs", path: "/v1/price/bitcoin/xyz", wantStatus: http.StatusNotFound},
+		{name: "upstream error", path: "/v1/price/ethereum/usd", wantStatus: http.StatusBadGateway},
+		{name: "upstream unknown id", path: "/v1/price/solana/usd", wantStatus: http.StatusNotFound},
+		{name: "upstream rate limited", path: "/v1/price/ripple/usd", wantStatus: http.StatusTooManyRequests, wantRetry: "30"},
+		{name: "upstream rate limited under a second", path: "/v1/price/dogecoin/usd", wantStatus: http.StatusTooManyRequests, wantRetry: "1"},
+		{name: "upstream unavailable", path: "/v1/price/cardano/usd", wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status: want %d got %d (body %s)", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not json: %v", err)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tc.wantRetry {
+				t.Fatalf("want Retry-After %q, got %q", tc.wantRetry, got)
+			}
+			if tc.wantStatus != http.StatusOK {
+				msg, _ := body["error"].(string)
+				if msg == "" || msg == "secret upstream details" {
+					t.Fatalf("unexpected error message: %q", msg)
+				}
+				return
+			}
+			parts := strings.Split(tc.path, "/") // /v1/price/{id}/{vs}
+			if body["id"] != parts[3] || body["vs"] != parts[4] || body["price"] != tc.wantPrice {
+				t.Fatalf("unexpected body: %v", body)
+			}
+			if cached, ok := body["cached"]; !ok || cached != false {
+				t.Fatalf("want cached=false for a backend price, got %v", body)
+			}
+		})
+	}
+}
+
+func TestHandlers_Rates(t *testing.T) {
+	fake := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}:  100,
+			{ID: "bitcoin", VS: "eur"}:  90,
+			{ID: "ethereum", VS: "usd"}: 10,
+			{ID: "ethereum", VS: "eur"}: 9,
+		},
+	}
+	mux := newMux(fake)
+
+	t.Run("cross product", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rates?ids=bitcoin,ethereum&vs=usd,eur", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status: want 200 got %d (body %s)", rec.Code, rec.Body.String())
+		}
+		var got map[string]map[string]float64
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got["bitcoin"]["eur"] != 90 || got["ethereum"]["usd"] != 10 || len(got["bitcoin"]) != 2 {
+			t.Fatalf("unexpected body: %v", got)
+		}
+	})
+
+	t.Run("partial errors", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rates?ids=bitcoin&vs=usd,rub", nil))
+		if rec.Code != http.StatusPartialContent {
+			t.Fatalf("status: want 206 got %d (body %s)", rec.Code, rec.Body.String())
+		}
+		var got struct {
+			Data   map[string]map[string]float64 `json:"data"`
+			Errors map[string]map[string]string  `json:"errors"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.Data["bitcoin"]["usd"] != 100 || got.Errors["bitcoin"]["rub"] == "" {
+			t.Fatalf("unexpected body: %+v", got)
+		}
+	})
+
+	for _, q := range []string{"?ids=bitcoin", "?ids=notacoin&vs=usd", "?ids=bitcoin&vs=xyz"} {
+		q := q
+		t.Run("bad query "+q, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rates"+q, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status: want 400 got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package app_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/boxdancer/go-currency-tracker/internal/app"
+	"github.com/boxdancer/go-currency-tracker/internal/config"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+	"go.uber.org/zap"
+)
+
+func TestApp_FromConfig(t *testing.T) {
+	var upstreamCalls atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100,"eur":90}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := config.Default()
+	cfg.CoinGecko.BaseURL = upstream.URL
+	cfg.Rates.DefaultPairs = config.PairList{{ID: "bitcoin", VS: "usd"}, {ID: "bitcoin", VS: "eur"}}
+	cfg.Poller.Enabled = false
+
+	a, err := app.New(cfg, nil, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := a.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rates", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var rates map[string]map[string]float64
+	if err := json.NewDecoder(rec.Body).Decode(&rates); err != nil {
+		t.Fatal(err)
+	}
+	if rates["bitcoin"]["usd"] != 100 || rates["bitcoin"]["eur"] != 90 {
+		t.Fatalf("configured default pairs must be served, got %v", rates)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/price/bitcoin/usd", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if upstreamCalls.Load() != 1 {
+		t.Fatalf("second request must be a cache hit, got %d upstream calls", upstreamCalls.Load())
+	}
+}
+
+func TestApp_RejectsInvalidConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Cache.Backend = "redis" // без redis_addr
+	if _, err := app.New(cfg, nil, zap.NewNop().Sugar()); err == nil {
+		t.Fatal("want error for invalid config")
+	}
+}
+
+func TestApp_Reload(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100,"eur":90}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := config.Default()
+	cfg.CoinGecko.BaseURL = upstream.URL
+	cfg.Rates.DefaultPairs = config.PairList{{ID: "bitcoin", VS: "usd"}}
+	cfg.Poller.Enabled = false
+
+	next, loadErr := cfg, error(nil)
+	load := func() (config.Config, error) { return next, loadErr }
+	m := testutil.NewRecordingMetrics()
+	level := zap.NewAtomicLevelAt(zap.DebugLevel)
+	a, err := app.New(cfg, m, zap.NewNop().Sugar(), app.WithReload(load, ""), app.WithLogLevel(level))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates := func() map[string]map[string]float64 {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rates", nil))
+		var out map[string]map[string]float64
+		if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if _, ok := rates()["bitcoin"]["eur"]; ok {
+		t.Fatal("eur must not be served before reload")
+	}
+
+	next.Rates.DefaultPairs = config.PairList{{ID: "bitcoin", VS: "eur"}}
+	next.Log.Level = "warn"
+	next.HTTP.Addr = ":9999" // требует перезапуска
+	if err := a.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := rates(); got["bitcoin"]["eur"] != 90 || len(got["bitcoin"]) != 1 {
+		t.Fatalf("reloaded default pairs must be served, got %v", got)
+	}
+	if level.Level() != zap.WarnLevel {
+		t.Fatalf("log level must follow config, got %v", level.Level())
+	}
+	if got := a.Config(); got.HTTP.Addr != cfg.HTTP.Addr || got.Log.Level != "warn" {
+		t.Fatalf("static fields must be kept, got addr=%q level=%q", got.HTTP.Addr, got.Log.Level)
+	}
+
+	loadErr = errors.New("broken file")
+	if err := a.Reload(); err == nil {
+		t.Fatal("want reload error")
+	}
+	if got := rates(); got["bitcoin"]["eur"] != 90 {
+		t.Fatalf("failed reload must keep current config, got %v", got)
+	}
+	if m.Count("config_reload:ok") != 1 || m.Count("config_reload:fail") != 1 {
+		t.Fatalf("unexpected reload metrics: ok=%d fail=%d", m.Count("config_reload:ok"), m.Count("config_reload:fail"))
+	}
+}
@@ -0,0 +1,141 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/cache"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func TestMemoryCache_GetSet(t *testing.T) {
+	c := cache.NewMemoryCache(10, time.Minute, nil)
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("want ErrMiss, got %v", err)
+	}
+	if err := c.Set(ctx, "k", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(ctx, "k", []byte("v2"))
+	if v, err := c.Get(ctx, "k"); err != nil || v != "v2" {
+		t.Fatalf("want v2, got %q, %v", v, err)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("overwrite must not add entries, len=%d", c.Len())
+	}
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	m := testutil.NewRecordingMetrics()
+	c := cache.NewMemoryCache(2, 0, m)
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "a", []byte("1"))
+	_ = c.Set(ctx, "b", []byte("2"))
+	_, _ = c.Get(ctx, "a") // a теперь используется недавно
+	_ = c.Set(ctx, "c", []byte("3"))
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("least recently used key must be evicted, got %v", err)
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, err := c.Get(ctx, k); err != nil {
+			t.Fatalf("key %q must stay: %v", k, err)
+		}
+	}
+	if c.Len() != 2 {
+		t.Fatalf("want len 2, got %d", c.Len())
+	}
+	if got := m.Count("evict:memory:capacity"); got != 1 {
+		t.Fatalf("want 1 capacity eviction, got %d", got)
+	}
+}
+
+func TestMemoryCache_TTL(t *testing.T) {
+	m := testutil.NewRecordingMetrics()
+	c := cache.NewMemoryCache(10, 20*time.Millisecond, m)
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "k", []byte("v"))
+	if _, err := c.Get(ctx, "k"); err != nil {
+		t.Fatalf("fresh entry: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("expired entry must be a miss, got %v", err)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expired entry must be removed, len=%d", c.Len())
+	}
+	if got := m.Count("evict:memory:expired"); got != 1 {
+		t.Fatalf("want 1 expired eviction, got %d", got)
+	}
+}
+
+func TestMemoryCache_Concurrent(t *testing.T) {
+	c := cache.NewMemoryCache(50, time.Minute, nil)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				k := fmt.Sprintf("k%d", (i*200+j)%100)
+				_ = c.Set(ctx, k, []byte(k))
+				if v, err := c.Get(ctx, k); err == nil && v != k {
+					t.Errorf("key %q has value %q", k, v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() > 50 {
+		t.Fatalf("size limit exceeded: %d", c.Len())
+	}
+}
+
+func TestMemoryCache_SetWithTTLAndDelete(t *testing.T) {
+	c := cache.NewMemoryCache(10, time.Hour, nil)
+	ctx := context.Background()
+
+	_ = c.SetWithTTL(ctx, "short", []byte("1"), 20*time.Millisecond)
+	_ = c.Set(ctx, "long", []byte("2"))
+	_ = c.MSet(ctx, map[string][]byte{"a": []byte("3"), "b": []byte("4")}, time.Hour)
+	_ = c.MSet(ctx, map[string][]byte{"c": []byte("5")}, 20*time.Millisecond)
+	if err := c.SetWithTTL(ctx, "negative", []byte("6"), -time.Second); !errors.Is(err, cache.ErrInvalidTTL) {
+		t.Fatalf("negative TTL: want ErrInvalidTTL, got %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	got, err := c.MGet(ctx, []string{"short", "long", "a", "b", "c", "negative", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"long": "2", "a": "3", "b": "4"}
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("key %q: want %q, got %q", k, v, got[k])
+		}
+	}
+
+	if err := c.Delete(ctx, "long"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "long"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("deleted key must be a miss, got %v", err)
+	}
+	if err := c.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("deleting a missing key must not fail: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/cache"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func TestTieredCache_Get(t *testing.T) {
+	m := testutil.NewRecordingMetrics()
+	l2 := testutil.NewFakeCache()
+	c := cache.NewTieredCache(cache.NewMemoryCache(10, time.Minute, nil), l2, m)
+	ctx := context.Background()
+
+	_ = l2.Set(ctx, "k", []byte("v"))
+
+	// первый Get: промах L1, попадание L2, L1 заполняется
+	if v, err := c.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("want v, got %q, %v", v, err)
+	}
+	// второй Get обслуживается L1
+	if v, err := c.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("want v, got %q, %v", v, err)
+	}
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("want ErrMiss, got %v", err)
+	}
+
+	want := map[string]int{"tier:l1:hit": 1, "tier:l1:miss": 2, "tier:l2:hit": 1, "tier:l2:miss": 1}
+	for name, n := range want {
+		if got := m.Count(name); got != n {
+			t.Fatalf("%s: want %d, got %d", name, n, got)
+		}
+	}
+}
+
+func TestTieredCache_SetWritesBothTiers(t *testing.T) {
+	l1 := cache.NewMemoryCache(10, time.Minute, nil)
+	l2 := testutil.NewFakeCache()
+	c := cache.NewTieredCache(l1, l2, nil)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := l1.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("L1: want v, got %q, %v", v, err)
+	}
+	if v, err := l2.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("L2: want v, got %q, %v", v, err)
+	}
+}
+
+func TestTieredCache_MGet(t *testing.T) {
+	m := testutil.NewRecordingMetrics()
+	l1 := cache.NewMemoryCache(10, time.Minute, nil)
+	l2 := testutil.NewFakeCache()
+	c := cache.NewTieredCache(l1, l2, m)
+	ctx := context.Background()
+
+	_ = l1.Set(ctx, "a", []byte("1"))
+	_ = l2.Set(ctx, "b", []byte("2"))
+
+	got, err := c.MGet(ctx, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"] != "1" || got["b"] != "2" {
+		t.Fatalf("unexpected result: %v", got)
+	}
+	if _, mgets := l2.Calls(); mgets != 1 {
+		t.Fatalf("want 1 L2 MGet, got %d", mgets)
+	}
+	if v, err := l1.Get(ctx, "b"); err != nil || v != "2" {
+		t.Fatalf("L2 hit must fill L1, got %q, %v", v, err)
+	}
+	want := map[string]int{"tier:l1:hit": 1, "tier:l1:miss": 2, "tier:l2:hit": 1, "tier:l2:miss": 1}
+	for name, n := range want {
+		if got := m.Count(name); got != n {
+			t.Fatalf("%s: want %d, got %d", name, n, got)
+		}
+	}
+}
+
+func TestTieredCache_DeleteAndTTL(t *testing.T) {
+	l1 := cache.NewMemoryCache(10, time.Minute, nil)
+	l2 := testutil.NewFakeCache()
+	c := cache.NewTieredCache(l1, l2, nil)
+	ctx := context.Background()
+
+	if err := c.SetWithTTL(ctx, "k", []byte("v"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got := l2.TTL("k"); got != time.Hour {
+		t.Fatalf("L2 must get the per-key TTL, got %s", got)
+	}
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l1.Get(ctx, "k"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("L1: want ErrMiss, got %v", err)
+	}
+	if _, err := l2.Get(ctx, "k"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("L2: want ErrMiss, got %v", err)
+	}
+}
+
+// bus — in-memory Invalidator: доставляет сообщения всем подписчикам, кроме отправителя.
+type bus struct {
+	mu   sync.Mutex
+	subs map[*busNode]func(string)
+}
+
+type busNode struct {
+	b        *bus
+	failures int // сколько первых вызовов Subscribe завершатся ошибкой
+}
+
+func (n *busNode) Publish(_ context.Context, key string) error {
+	n.b.mu.Lock()
+	defer n.b.mu.Unlock()
+	for sub, fn := range n.b.subs {
+		if sub != n {
+			fn(key)
+		}
+	}
+	return nil
+}
+
+func (n *busNode) Subscribe(ctx context.Context, ready func(), fn func(string)) error {
+	n.b.mu.Lock()
+	if n.failures > 0 {
+		n.failures--
+		n.b.mu.Unlock()
+		return errors.New("connection refused")
+	}
+	n.b.subs[n] = fn
+	n.b.mu.Unlock()
+	ready()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTieredCache_Invalidation(t *testing.T) {
+	b := &bus{subs: make(map[*busNode]func(string))}
+	l2 := testutil.NewFakeCache()
+	replicaA := cache.NewTieredCache(cache.NewMemoryCache(10, time.Minute, nil), l2, nil, cache.WithInvalidation(&busNode{b: b}))
+	replicaB := cache.NewTieredCache(cache.NewMemoryCache(10, time.Minute, nil), l2, nil, cache.WithInvalidation(&busNode{b: b}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 2)
+	go func() { done <- replicaA.Listen(ctx) }()
+	go func() { done <- replicaB.Listen(ctx) }()
+	waitFor(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.subs) == 2
+	})
+
+	_ = replicaA.Set(ctx, "k", []byte("old"))
+	if v, _ := replicaB.Get(ctx, "k"); v != "old" { // попадает в L1 реплики B
+		t.Fatalf("want old, got %q", v)
+	}
+
+	_ = replicaA.Set(ctx, "k", []byte("new"))
+	if v, _ := replicaB.Get(ctx, "k"); v != "new" {
+		t.Fatalf("replica B must drop stale L1 entry, got %q", v)
+	}
+	if v, _ := replicaA.Get(ctx, "k"); v != "new" {
+		t.Fatalf("writer must keep its own L1 entry, got %q", v)
+	}
+
+	cancel()
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Fatalf("Listen must return nil on cancel, got %v", err)
+		}
+	}
+}
+
+// Подписка, не установленная при старте, восстанавливается с повторами.
+func TestTieredCache_ListenRetriesSubscribe(t *testing.T) {
+	b := &bus{subs: make(map[*busNode]func(string))}
+	l2 := testutil.NewFakeCache()
+	m := testutil.NewRecordingMetrics()
+	writer := cache.NewTieredCache(cache.NewMemoryCache(10, time.Minute, nil), l2, nil, cache.WithInvalidation(&busNode{b: b}))
+	reader := cache.NewTieredCache(cache.NewMemoryCache(10, time.Minute, nil), l2, m,
+		cache.WithInvalidation(&busNode{b: b, failures: 3}),
+		cache.WithResubscribeBackoff(time.Millisecond, 5*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- reader.Listen(ctx) }()
+	waitFor(t, func() bool { return m.Gauge("invalidation_subscribed") == 1 })
+
+	_ = writer.Set(ctx, "k", []byte("old"))
+	if v, _ := reader.Get(ctx, "k"); v != "old" {
+		t.Fatalf("want old, got %q", v)
+	}
+	_ = writer.Set(ctx, "k", []byte("new"))
+	if v, _ := reader.Get(ctx, "k"); v != "new" {
+		t.Fatalf("reader must drop stale L1 entry after resubscribing, got %q", v)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Listen must return nil on cancel, got %v", err)
+	}
+	if m.Gauge("invalidation_subscribed") != 0 {
+		t.Fatalf("subscription gauge must drop after Listen stops")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
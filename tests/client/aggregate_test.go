@@ -0,0 +1,128 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+var btcUSD = testutil.Key{ID: "bitcoin", VS: "usd"}
+
+func fakeQuotes(name string, p, volume float64) *testutil.FakeQuoteClient {
+	return &testutil.FakeQuoteClient{
+		FakePriceClient: testutil.FakePriceClient{Responses: map[testutil.Key]float64{btcUSD: p}},
+		Source:          name,
+		Volumes:         map[testutil.Key]float64{btcUSD: volume},
+	}
+}
+
+func TestAggregator_MedianRejectsOutlier(t *testing.T) {
+	m := testutil.NewRecordingMetrics()
+	a := client.NewAggregator(map[string]price.PriceClient{
+		"binance":  fakeQuotes("binance", 100, 5),
+		"kraken":   fakeQuotes("kraken", 101, 1),
+		"coinbase": fakeQuotes("coinbase", 150, 0), // плохой тик
+	}, client.AggregateConfig{MinSources: 2, MaxDeviation: 0.02}, m)
+
+	q, err := a.GetQuote(context.Background(), "bitcoin", "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Price != 100.5 || q.Sources != 2 || q.Source != "binance,kraken" || q.Volume != 6 {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	if q.FetchedAt.IsZero() {
+		t.Fatal("aggregated quote must keep FetchedAt")
+	}
+	if m.Count("aggregate_outlier:coinbase") != 1 || m.Count("aggregate_outlier:binance") != 0 {
+		t.Fatal("outlier must be recorded for coinbase only")
+	}
+	if got := m.Gauge("aggregate_spread"); math.Abs(got-50.0/101) > 1e-9 {
+		t.Fatalf("want spread 50/101, got %v", got)
+	}
+}
+
+func TestAggregator_VWAP(t *testing.T) {
+	a := client.NewAggregator(map[string]price.PriceClient{
+		"binance":  fakeQuotes("binance", 100, 3),
+		"kraken":   fakeQuotes("kraken", 104, 1),
+		"coinbase": fakeQuotes("coinbase", 102, 0), // без объёма в среднее не входит
+	}, client.AggregateConfig{MinSources: 3, MaxDeviation: 0.05, Method: client.AggregateVWAP}, nil)
+
+	q, err := a.GetQuote(context.Background(), "bitcoin", "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Price != 101 || q.Sources != 3 {
+		t.Fatalf("want vwap 101 from 3 sources, got %+v", q)
+	}
+}
+
+func TestAggregator_Quorum(t *testing.T) {
+	limited := &testutil.FakePriceClient{Errors: map[testutil.Key]error{btcUSD: &price.RateLimitError{}}}
+	m := testutil.NewRecordingMetrics()
+	a := client.NewAggregator(map[string]price.PriceClient{
+		"binance":  fakeQuotes("binance", 100, 0),
+		"kraken":   limited,
+		"coinbase": fakeQuotes("coinbase", 120, 0),
+	}, client.AggregateConfig{MinSources: 2, MaxDeviation: 0.05}, m)
+
+	_, err := a.GetQuote(context.Background(), "bitcoin", "usd")
+	var qe *price.QuorumError
+	if !errors.As(err, &qe) || qe.Need != 2 || qe.Got != 0 {
+		t.Fatalf("want quorum error 0 of 2, got %v", err)
+	}
+	if !errors.Is(err, price.ErrNoQuorum) || !errors.Is(err, price.ErrUpstreamUnavailable) {
+		t.Fatalf("quorum error must match ErrNoQuorum and ErrUpstreamUnavailable: %v", err)
+	}
+	if errors.Is(err, price.ErrRateLimited) {
+		t.Fatal("provider error must not leak through errors.Is")
+	}
+	if m.Count("aggregate_no_quorum") != 1 {
+		t.Fatal("quorum failure not recorded")
+	}
+}
+
+func TestAggregator_UnknownPair(t *testing.T) {
+	unknown := func() *testutil.FakePriceClient {
+		return &testutil.FakePriceClient{Errors: map[testutil.Key]error{{ID: "bitcoin", VS: "rub"}: price.ErrUnknownVS}}
+	}
+	a := client.NewAggregator(map[string]price.PriceClient{"binance": unknown(), "kraken": unknown()},
+		client.DefaultAggregateConfig(), nil)
+
+	if _, err := a.GetQuote(context.Background(), "bitcoin", "rub"); !errors.Is(err, price.ErrUnknownVS) || errors.Is(err, price.ErrNoQuorum) {
+		t.Fatalf("pair unknown to every provider must be unknown, got %v", err)
+	}
+}
+
+func TestAggregator_GetQuotes(t *testing.T) {
+	binance := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		btcUSD:                      100,
+		{ID: "ethereum", VS: "usd"}: 10,
+	}}}
+	kraken := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{btcUSD: 102, {ID: "ethereum", VS: "usd"}: 20},
+		Errors:    map[testutil.Key]error{{ID: "solana", VS: "usd"}: price.ErrUnknownID},
+	}
+	a := client.NewAggregator(map[string]price.PriceClient{"binance": binance, "kraken": kraken},
+		client.AggregateConfig{MinSources: 2, MaxDeviation: 0.05}, nil)
+
+	quotes, err := a.GetQuotes(context.Background(), []string{"bitcoin", "ethereum", "solana"}, []string{"usd"})
+	if q := quotes[price.Pair{ID: "bitcoin", VS: "usd"}]; q.Price != 101 || q.Sources != 2 {
+		t.Fatalf("unexpected bitcoin quote: %+v", q)
+	}
+	if quotes.HasID("ethereum") || quotes.HasID("solana") {
+		t.Fatalf("pairs without quorum or unknown must be absent: %v", quotes.Prices())
+	}
+	if !errors.Is(err, price.ErrNoQuorum) {
+		t.Fatalf("want quorum error for ethereum, got %v", err)
+	}
+	if binance.Calls() != 1 {
+		t.Fatalf("batch provider must be asked once, got %d calls", binance.Calls())
+	}
+}
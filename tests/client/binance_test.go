@@ -0,0 +1,143 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+func TestBinanceClient_GetQuote(t *testing.T) {
+	tests := []struct {
+		name       string
+		id, vs     string
+		handler    http.HandlerFunc
+		wantSymbol string // ожидаемый symbol в запросе; "" — запроса быть не должно
+		want       float64
+		wantVol    float64
+		wantErrIs  error
+	}{
+		{
+			name: "success usd via usdt",
+			id:   "bitcoin",
+			vs:   "usd",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","lastPrice":"43000.12000000","volume":"20512.5"}`))
+			},
+			wantSymbol: "BTCUSDT",
+			want:       43000.12,
+			wantVol:    20512.5,
+		},
+		{
+			name: "invalid symbol",
+			id:   "bitcoin",
+			vs:   "rub",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+			},
+			wantSymbol: "BTCRUB",
+			wantErrIs:  price.ErrUnknownVS,
+		},
+		{
+			name: "rate limited",
+			id:   "ethereum",
+			vs:   "eur",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantSymbol: "ETHEUR",
+			wantErrIs:  price.ErrRateLimited,
+		},
+		{
+			name: "server error",
+			id:   "ethereum",
+			vs:   "eur",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantSymbol: "ETHEUR",
+			wantErrIs:  price.ErrUpstreamUnavailable,
+		},
+		{
+			name: "bad price",
+			id:   "bitcoin",
+			vs:   "usd",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","lastPrice":"n/a"}`))
+			},
+			wantSymbol: "BTCUSDT",
+			wantErrIs:  price.ErrBadPayload,
+		},
+		{
+			name:      "unknown id without request",
+			id:        "no-such-coin",
+			vs:        "usd",
+			wantErrIs: price.ErrUnknownID,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath, gotSymbol string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotSymbol = r.URL.Path, r.URL.Query().Get("symbol")
+				tc.handler(w, r)
+			}))
+			defer ts.Close()
+
+			c := client.NewBinanceClient(5 * time.Second)
+			c.SetBaseURL(ts.URL)
+
+			q, err := c.GetQuote(context.Background(), tc.id, tc.vs)
+			if gotSymbol != tc.wantSymbol {
+				t.Fatalf("want symbol %q, got %q", tc.wantSymbol, gotSymbol)
+			}
+			if tc.wantSymbol != "" && gotPath != "/api/v3/ticker/24hr" {
+				t.Fatalf("unexpected path %q", gotPath)
+			}
+			if tc.wantErrIs != nil {
+				if !errors.Is(err, tc.wantErrIs) {
+					t.Fatalf("expected errors.Is(err, %v), got: %v", tc.wantErrIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if q.Price != tc.want || q.Volume != tc.wantVol || q.Source != client.BinanceName || q.FetchedAt.IsZero() {
+				t.Fatalf("unexpected quote: %+v", q)
+			}
+		})
+	}
+}
+
+func TestBinanceClient_RetriesServerErrors(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","lastPrice":"100"}`))
+	}))
+	defer ts.Close()
+
+	c := client.NewBinanceClient(5 * time.Second)
+	c.SetBaseURL(ts.URL)
+	c.SetRetryPolicy(client.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	got, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+	if err != nil || got != 100 {
+		t.Fatalf("want 100 after retry, got %v, %v", got, err)
+	}
+	if requests != 2 {
+		t.Fatalf("want 2 requests, got %d", requests)
+	}
+}
@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	unavailable := fmt.Errorf("%w: boom", price.ErrUpstreamUnavailable)
+	fake := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Errors:    map[testutil.Key]error{{ID: "ethereum", VS: "usd"}: unavailable},
+	}
+	m := testutil.NewRecordingMetrics()
+	cfg := client.BreakerConfig{FailureThreshold: 3, CoolDown: 50 * time.Millisecond, HalfOpenMaxCalls: 1}
+	b := client.NewCircuitBreaker("test", fake, cfg, m)
+	ctx := context.Background()
+
+	// Ошибки до порога проходят в backend, затем цепь размыкается.
+	for i := 0; i < 3; i++ {
+		if _, err := b.GetPrice(ctx, "ethereum", "usd"); !errors.Is(err, unavailable) {
+			t.Fatalf("call %d: expected backend error, got %v", i, err)
+		}
+	}
+	if b.State() != client.BreakerOpen || m.Gauge("breaker:test") != float64(client.BreakerOpen) {
+		t.Fatalf("expected open breaker, got %v", b.State())
+	}
+
+	// В open backend не вызывается, ошибка типизирована.
+	calls := fake.Calls()
+	_, err := b.GetPrice(ctx, "bitcoin", "usd")
+	if !errors.Is(err, price.ErrCircuitOpen) || !errors.Is(err, price.ErrUpstreamUnavailable) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if fake.Calls() != calls {
+		t.Fatalf("backend must not be called while open")
+	}
+
+	// После cool-down пробный запрос с ошибкой снова размыкает цепь.
+	time.Sleep(60 * time.Millisecond)
+	if b.State() != client.BreakerHalfOpen {
+		t.Fatalf("expected half-open after cool-down, got %v", b.State())
+	}
+	if _, err := b.GetPrice(ctx, "ethereum", "usd"); !errors.Is(err, unavailable) {
+		t.Fatalf("expected probe to reach backend, got %v", err)
+	}
+	if b.State() != client.BreakerOpen {
+		t.Fatalf("failed probe must reopen breaker, got %v", b.State())
+	}
+
+	// Успешный пробный запрос замыкает цепь.
+	time.Sleep(60 * time.Millisecond)
+	if got, err := b.GetPrice(ctx, "bitcoin", "usd"); err != nil || got != 100 {
+		t.Fatalf("expected successful probe, got %v, %v", got, err)
+	}
+	if b.State() != client.BreakerClosed || m.Gauge("breaker:test") != float64(client.BreakerClosed) {
+		t.Fatalf("expected closed breaker, got %v", b.State())
+	}
+}
+
+func TestCircuitBreaker_IgnoresPermanentErrors(t *testing.T) {
+	fake := &testutil.FakePriceClient{
+		Errors: map[testutil.Key]error{{ID: "nope", VS: "usd"}: price.ErrUnknownID},
+	}
+	b := client.NewCircuitBreaker("test", fake, client.BreakerConfig{FailureThreshold: 1, CoolDown: time.Minute}, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := b.GetPrice(context.Background(), "nope", "usd"); !errors.Is(err, price.ErrUnknownID) {
+			t.Fatalf("expected ErrUnknownID, got %v", err)
+		}
+	}
+	if b.State() != client.BreakerClosed {
+		t.Fatalf("unknown id must not open the breaker, got %v", b.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	fake := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Errors:    map[testutil.Key]error{{ID: "ethereum", VS: "usd"}: price.ErrUpstreamUnavailable},
+		Delays:    map[testutil.Key]time.Duration{{ID: "bitcoin", VS: "usd"}: 50 * time.Millisecond},
+	}
+	cfg := client.BreakerConfig{FailureThreshold: 1, CoolDown: 10 * time.Millisecond, HalfOpenMaxCalls: 1}
+	b := client.NewCircuitBreaker("test", fake, cfg, nil)
+	ctx := context.Background()
+
+	_, _ = b.GetPrice(ctx, "ethereum", "usd")
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.GetPrice(ctx, "bitcoin", "usd") // пробный запрос, висит 50ms
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := b.GetPrice(ctx, "bitcoin", "usd"); !errors.Is(err, price.ErrCircuitOpen) {
+		t.Fatalf("second concurrent probe must be rejected, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+}
+
+// Backend, который не успевает ответить до дедлайна вызывающего, считается неисправным.
+func TestCircuitBreaker_CallerDeadlineCountsAsFailure(t *testing.T) {
+	fake := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Delay:     time.Second,
+	}
+	b := client.NewCircuitBreaker("test", fake, client.BreakerConfig{FailureThreshold: 3, CoolDown: time.Minute}, nil)
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		_, err := b.GetPrice(ctx, "bitcoin", "usd")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("call %d: expected deadline exceeded, got %v", i, err)
+		}
+	}
+	if b.State() != client.BreakerOpen {
+		t.Fatalf("hanging backend must open the breaker, got %v", b.State())
+	}
+}
+
+// Отмена вызывающим лишь освобождает пробный слот: цепь остаётся half-open.
+func TestCircuitBreaker_CancelledProbeKeepsHalfOpen(t *testing.T) {
+	fake := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Errors:    map[testutil.Key]error{{ID: "ethereum", VS: "usd"}: price.ErrUpstreamUnavailable},
+		Delays:    map[testutil.Key]time.Duration{{ID: "bitcoin", VS: "usd"}: time.Second},
+	}
+	cfg := client.BreakerConfig{FailureThreshold: 1, CoolDown: 10 * time.Millisecond, HalfOpenMaxCalls: 1}
+	b := client.NewCircuitBreaker("test", fake, cfg, nil)
+
+	_, _ = b.GetPrice(context.Background(), "ethereum", "usd")
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(5*time.Millisecond, cancel)
+	if _, err := b.GetPrice(ctx, "bitcoin", "usd"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled probe, got %v", err)
+	}
+	if b.State() != client.BreakerHalfOpen {
+		t.Fatalf("cancelled probe must not change state, got %v", b.State())
+	}
+	// слот освобождён: следующий пробный запрос доходит до backend
+	if _, err := b.GetPrice(context.Background(), "ethereum", "usd"); !errors.Is(err, price.ErrUpstreamUnavailable) || errors.Is(err, price.ErrCircuitOpen) {
+		t.Fatalf("expected probe to reach backend, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func TestCachedPriceClient_GetPrices(t *testing.T) {
+	backend := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}:  100,
+			{ID: "bitcoin", VS: "eur"}:  90,
+			{ID: "ethereum", VS: "usd"}: 10,
+			{ID: "ethereum", VS: "eur"}: 9,
+		},
+	}}
+	c := testutil.NewFakeCache()
+	cached := client.NewCachedPriceClient(backend, c, nil)
+	ctx := context.Background()
+
+	// bitcoin/usd уже в кэше: backend должен получить только промахи и один раз.
+	if _, err := cached.GetPrice(ctx, "bitcoin", "usd"); err != nil {
+		t.Fatalf("warm up: %v", err)
+	}
+	callsBefore := backend.Calls()
+
+	got, err := cached.GetPrices(ctx, []string{"bitcoin", "ethereum"}, []string{"usd", "eur"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := backend.Calls() - callsBefore; calls != 1 {
+		t.Fatalf("want 1 batch backend call, got %d", calls)
+	}
+	if got["bitcoin"]["usd"] != 100 || got["ethereum"]["eur"] != 9 || len(got) != 2 {
+		t.Fatalf("unexpected prices: %v", got)
+	}
+	if c.Len() != 4 {
+		t.Fatalf("want 4 cached pairs, got %d", c.Len())
+	}
+	if _, mgets := c.Calls(); mgets != 1 {
+		t.Fatalf("want all pairs looked up with 1 MGet, got %d", mgets)
+	}
+
+	// Повторный запрос целиком из кэша.
+	if _, err := cached.GetPrices(ctx, []string{"bitcoin", "ethereum"}, []string{"usd", "eur"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := backend.Calls() - callsBefore; calls != 1 {
+		t.Fatalf("expected cache hits only, backend calls: %d", calls)
+	}
+}
+
+func TestCachedPriceClient_GetPairs(t *testing.T) {
+	// backend без batch-API: каждая запрошенная у него пара — отдельный вызов
+	backend := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}:  100,
+			{ID: "ethereum", VS: "usd"}: 10,
+			{ID: "usd", VS: "rub"}:      90,
+		},
+	}
+	c := testutil.NewFakeCache()
+	cached := client.NewCachedPriceClient(backend, c, nil)
+
+	pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}, {ID: "ethereum", VS: "usd"}, {ID: "usd", VS: "rub"}}
+	got, err := cached.GetPairs(context.Background(), pairs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 || got[pairs[2]].Price != 90 {
+		t.Fatalf("unexpected quotes: %v", got)
+	}
+	// список не расширяется до ids × vs (bitcoin/rub, usd/usd и т.д.)
+	if calls := backend.Calls(); calls != 3 {
+		t.Fatalf("want 3 backend calls, got %d", calls)
+	}
+	if c.Len() != 3 {
+		t.Fatalf("want 3 cached pairs, got %d", c.Len())
+	}
+}
+
+func TestCachedPriceClient_Coalescing(t *testing.T) {
+	backend := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}:  100,
+			{ID: "ethereum", VS: "usd"}: 10,
+		},
+		Delay: 50 * time.Millisecond,
+	}}
+	m := testutil.NewRecordingMetrics()
+	cached := client.NewCachedPriceClient(backend, testutil.NewFakeCache(), m)
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if v, err := cached.GetPrice(context.Background(), "bitcoin", "usd"); err != nil || v != 100 {
+				errs <- fmt.Errorf("GetPrice: %v, %v", v, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			got, err := cached.GetPrices(context.Background(), []string{"ethereum"}, []string{"usd"})
+			if err != nil || got["ethereum"]["usd"] != 10 {
+				errs <- fmt.Errorf("GetPrices: %v, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if calls := backend.Calls(); calls != 2 {
+		t.Fatalf("want 2 backend calls (one per key), got %d", calls)
+	}
+	if got := m.Count("coalesced"); got != 2*n-2 {
+		t.Fatalf("want %d coalesced calls, got %d", 2*n-2, got)
+	}
+}
+
+// Если leader отменил свой запрос, ожидающий с живым контекстом запрашивает цену сам.
+func TestCachedPriceClient_CoalescingLeaderCancelled(t *testing.T) {
+	backend := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Delay:     50 * time.Millisecond,
+	}
+	cached := client.NewCachedPriceClient(backend, testutil.NewFakeCache(), nil)
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := cached.GetPrice(leaderCtx, "bitcoin", "usd")
+		leaderDone <- err
+	}()
+	time.Sleep(2 * time.Millisecond)
+
+	v, err := cached.GetPrice(context.Background(), "bitcoin", "usd")
+	if err != nil || v != 100 {
+		t.Fatalf("follower: want 100, nil; got %v, %v", v, err)
+	}
+	if err := <-leaderDone; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("leader: expected deadline exceeded, got %v", err)
+	}
+}
+
+// Таймаут самого backend (как у http.Client) — обычная ошибка: ожидающие получают её
+// вместе с leader, а не повторяют запрос по очереди.
+func TestCachedPriceClient_CoalescingBackendTimeout(t *testing.T) {
+	timeout := fmt.Errorf("%w: Client.Timeout exceeded", context.DeadlineExceeded)
+	backend := &testutil.FakePriceClient{
+		Errors: map[testutil.Key]error{{ID: "bitcoin", VS: "usd"}: timeout},
+		Delay:  30 * time.Millisecond,
+	}
+	cached := client.NewCachedPriceClient(backend, testutil.NewFakeCache(), nil)
+
+	const n = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cached.GetPrice(context.Background(), "bitcoin", "usd")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want the backend timeout, got %v", err)
+		}
+	}
+	if calls := backend.Calls(); calls != 1 {
+		t.Fatalf("waiters must share the backend timeout, got %d backend calls", calls)
+	}
+}
@@ -0,0 +1,104 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+func TestCoinbaseClient_GetQuote(t *testing.T) {
+	tests := []struct {
+		name      string
+		id, vs    string
+		handler   http.HandlerFunc
+		wantPath  string
+		want      float64
+		wantErrIs error
+	}{
+		{
+			name: "success",
+			id:   "ethereum",
+			vs:   "eur",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data":{"amount":"2100.55","base":"ETH","currency":"EUR"}}`))
+			},
+			wantPath: "/v2/prices/ETH-EUR/spot",
+			want:     2100.55,
+		},
+		{
+			name: "invalid currency",
+			id:   "bitcoin",
+			vs:   "rub",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[{"id":"not_found","message":"Invalid currency"}]}`))
+			},
+			wantPath:  "/v2/prices/BTC-RUB/spot",
+			wantErrIs: price.ErrUnknownVS,
+		},
+		{
+			name: "rate limited",
+			id:   "bitcoin",
+			vs:   "usd",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantPath:  "/v2/prices/BTC-USD/spot",
+			wantErrIs: price.ErrRateLimited,
+		},
+		{
+			name: "bad json",
+			id:   "bitcoin",
+			vs:   "usd",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{not-json}`))
+			},
+			wantPath:  "/v2/prices/BTC-USD/spot",
+			wantErrIs: price.ErrBadPayload,
+		},
+		{
+			name:      "unknown vs without request",
+			id:        "bitcoin",
+			vs:        "no-such-fiat",
+			wantErrIs: price.ErrUnknownVS,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				tc.handler(w, r)
+			}))
+			defer ts.Close()
+
+			c := client.NewCoinbaseClient(5 * time.Second)
+			c.SetBaseURL(ts.URL)
+
+			q, err := c.GetQuote(context.Background(), tc.id, tc.vs)
+			if gotPath != tc.wantPath {
+				t.Fatalf("want path %q, got %q", tc.wantPath, gotPath)
+			}
+			if tc.wantErrIs != nil {
+				if !errors.Is(err, tc.wantErrIs) {
+					t.Fatalf("expected errors.Is(err, %v), got: %v", tc.wantErrIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if q.Price != tc.want || q.Source != client.CoinbaseName {
+				t.Fatalf("unexpected quote: %+v", q)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
 )
 
 // Table-driven tests for CoinGeckoClient.GetPrice.
@@ -22,6 +23,7 @@ func TestCoinGeckoClient_GetPrice(t *testing.T) {
 		ctxTimeout        time.Duration // if >0 create context with timeout
 		want              float64
 		wantErr           bool
+		wantErrIs         error  // typed error expected via errors.Is
 		assertErrContains string // substring to assert in error (case-insensitive)
 	}{
 		{
@@ -40,9 +42,19 @@ func TestCoinGeckoClient_GetPrice(t *testing.T) {
 			handler: func(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "boom", http.StatusInternalServerError)
 			},
-			clientTimeout:     5 * time.Second,
-			wantErr:           true,
-			assertErrContains: "unexpected status",
+			clientTimeout: 5 * time.Second,
+			wantErr:       true,
+			wantErrIs:     price.ErrUpstreamUnavailable,
+		},
+		{
+			name: "rate limited",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				http.Error(w, "slow down", http.StatusTooManyRequests)
+			},
+			clientTimeout: 5 * time.Second,
+			wantErr:       true,
+			wantErrIs:     price.ErrRateLimited,
 		},
 		{
 			name: "bad json",
@@ -50,9 +62,9 @@ func TestCoinGeckoClient_GetPrice(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 				_, _ = w.Write([]byte("{not-json}"))
 			},
-			clientTimeout:     5 * time.Second,
-			wantErr:           true,
-			assertErrContains: "decode json",
+			clientTimeout: 5 * time.Second,
+			wantErr:       true,
+			wantErrIs:     price.ErrBadPayload,
 		},
 		{
 			name: "missing id",
@@ -60,9 +72,9 @@ func TestCoinGeckoClient_GetPrice(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 				_, _ = w.Write([]byte(`{"other":{"usd":1}}`))
 			},
-			clientTimeout:     5 * time.Second,
-			wantErr:           true,
-			assertErrContains: "no id",
+			clientTimeout: 5 * time.Second,
+			wantErr:       true,
+			wantErrIs:     price.ErrUnknownID,
 		},
 		{
 			name: "missing vs",
@@ -70,9 +82,9 @@ func TestCoinGeckoClient_GetPrice(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 				_, _ = w.Write([]byte(`{"bitcoin":{"eur":1}}`))
 			},
-			clientTimeout:     5 * time.Second,
-			wantErr:           true,
-			assertErrContains: "no vs",
+			clientTimeout: 5 * time.Second,
+			wantErr:       true,
+			wantErrIs:     price.ErrUnknownVS,
 		},
 		{
 			name: "context timeout",
@@ -140,6 +152,12 @@ func TestCoinGeckoClient_GetPrice(t *testing.T) {
 					}
 					t.Fatalf("expected timeout error, got: %v", err)
 				}
+				if tc.wantErrIs != nil {
+					if !errors.Is(err, tc.wantErrIs) {
+						t.Fatalf("expected errors.Is(err, %v), got: %v", tc.wantErrIs, err)
+					}
+					return
+				}
 				// otherwise, check substring if provided
 				if tc.assertErrContains != "" {
 					if !strings.Contains(strings.ToLower(err.Error()), strings.ToLower(tc.assertErrContains)) &&
@@ -172,3 +190,81 @@ func errorsIsContextDeadline(err error) bool {
 	}
 	return false
 }
+
+func TestCoinGeckoClient_GetPrices(t *testing.T) {
+	var requests int
+	var gotIDs, gotVS string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		gotIDs, gotVS = r.URL.Query().Get("ids"), r.URL.Query().Get("vs_currencies")
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100,"eur":90},"ethereum":{"usd":10,"eur":9}}`))
+	}))
+	defer ts.Close()
+
+	c := client.NewCoinGeckoClient(5 * time.Second)
+	c.SetBaseURL(ts.URL)
+
+	got, err := c.GetPrices(context.Background(), []string{"bitcoin", "ethereum"}, []string{"usd", "eur"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("want 1 upstream request, got %d", requests)
+	}
+	if gotIDs != "bitcoin,ethereum" || gotVS != "usd,eur" {
+		t.Fatalf("unexpected query: ids=%q vs_currencies=%q", gotIDs, gotVS)
+	}
+	if got["bitcoin"]["eur"] != 90 || got["ethereum"]["usd"] != 10 {
+		t.Fatalf("unexpected prices: %v", got)
+	}
+}
+
+func TestCoinGeckoClient_RateLimitRetryAfter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c := client.NewCoinGeckoClient(5 * time.Second)
+	c.SetBaseURL(ts.URL)
+
+	_, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+	var rl *price.RateLimitError
+	if !errors.As(err, &rl) {
+		t.Fatalf("expected *price.RateLimitError, got: %v", err)
+	}
+	if rl.RetryAfter != 30*time.Second {
+		t.Fatalf("want RetryAfter 30s, got %v", rl.RetryAfter)
+	}
+}
+
+func TestCoinGeckoClient_GetQuotes(t *testing.T) {
+	var includeUpdated string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		includeUpdated = r.URL.Query().Get("include_last_updated_at")
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100,"eur":90,"last_updated_at":1700000000}}`))
+	}))
+	defer ts.Close()
+
+	c := client.NewCoinGeckoClient(5 * time.Second)
+	c.SetBaseURL(ts.URL)
+
+	got, err := c.GetQuotes(context.Background(), []string{"bitcoin"}, []string{"usd", "eur"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if includeUpdated != "true" {
+		t.Fatalf("include_last_updated_at not requested")
+	}
+	if len(got) != 2 {
+		t.Fatalf("last_updated_at must not be parsed as a vs currency: %v", got)
+	}
+	q := got[price.Pair{ID: "bitcoin", VS: "eur"}]
+	if q.Price != 90 || q.Source != client.CoinGeckoName || q.FetchedAt.IsZero() {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	if !q.UpstreamUpdatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("want upstream updated at 1700000000, got %v", q.UpstreamUpdatedAt)
+	}
+}
@@ -0,0 +1,129 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+var errUnavailable = fmt.Errorf("%w: 503", price.ErrUpstreamUnavailable)
+
+func TestFailover_GetQuote(t *testing.T) {
+	primary := &testutil.FakePriceClient{Errors: map[testutil.Key]error{
+		btcUSD:                      &price.RateLimitError{},
+		{ID: "ethereum", VS: "usd"}: testutil.Err("bad request"),
+		{ID: "solana", VS: "usd"}:   errUnavailable,
+	}}
+	secondary := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{btcUSD: 100, {ID: "ethereum", VS: "usd"}: 10},
+		Errors:    map[testutil.Key]error{{ID: "solana", VS: "usd"}: price.ErrUnknownID},
+	}
+	m := testutil.NewRecordingMetrics()
+	f, err := client.NewFailover([]string{"coingecko", "binance"},
+		map[string]price.PriceClient{"coingecko": primary, "binance": secondary}, client.DefaultFailoverConfig(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	q, err := f.GetQuote(ctx, "bitcoin", "usd")
+	if err != nil || q.Price != 100 || q.Source != "binance" {
+		t.Fatalf("rate limited primary must fail over to binance, got %+v, %v", q, err)
+	}
+	if m.Count("failover:coingecko:failed") != 1 || m.Count("failover:binance:served") != 1 {
+		t.Fatal("failover results not recorded")
+	}
+
+	if _, err := f.GetQuote(ctx, "ethereum", "usd"); err == nil || secondary.Calls() != 1 {
+		t.Fatalf("non-retryable error must be returned without failover, got %v and %d calls", err, secondary.Calls())
+	}
+
+	// временная ошибка важнее «не знаю пару»: иначе пара попадёт в negative cache
+	_, err = f.GetQuote(ctx, "solana", "usd")
+	if !errors.Is(err, price.ErrUpstreamUnavailable) || errors.Is(err, price.ErrUnknownID) {
+		t.Fatalf("want unavailable error, got %v", err)
+	}
+
+	if _, err := client.NewFailover([]string{"kraken"}, map[string]price.PriceClient{}, client.DefaultFailoverConfig(), nil); err == nil {
+		t.Fatal("want error for unknown provider")
+	}
+}
+
+func TestFailover_DemotesUnhealthyProvider(t *testing.T) {
+	primary := &testutil.FakePriceClient{Errors: map[testutil.Key]error{btcUSD: errUnavailable}}
+	secondary := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{btcUSD: 100}}
+	m := testutil.NewRecordingMetrics()
+	f, err := client.NewFailover([]string{"coingecko", "binance"},
+		map[string]price.PriceClient{"coingecko": primary, "binance": secondary},
+		client.FailoverConfig{Decay: 0.5, DemoteBelow: 0.3, DemoteFor: time.Minute}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	f.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	for range 2 { // 1 → 0.5 → 0.25
+		if _, err := f.GetQuote(ctx, "bitcoin", "usd"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if score, demoted := f.Health("coingecko"); score != 0.25 || !demoted {
+		t.Fatalf("want coingecko demoted with score 0.25, got %v %v", score, demoted)
+	}
+	if m.Count("demoted:coingecko") != 1 || m.Gauge("health:coingecko") != 0.25 {
+		t.Fatal("demotion metrics not recorded")
+	}
+
+	if _, err := f.GetQuote(ctx, "bitcoin", "usd"); err != nil {
+		t.Fatal(err)
+	}
+	if primary.Calls() != 2 {
+		t.Fatalf("demoted provider must not be asked first, got %d calls", primary.Calls())
+	}
+
+	// после срока понижения провайдер снова первый, но одна ошибка понижает его опять
+	now = now.Add(time.Minute)
+	if _, err := f.GetQuote(ctx, "bitcoin", "usd"); err != nil {
+		t.Fatal(err)
+	}
+	if primary.Calls() != 3 {
+		t.Fatalf("restored provider must be asked first, got %d calls", primary.Calls())
+	}
+	if _, demoted := f.Health("coingecko"); !demoted || m.Count("demoted:coingecko") != 2 {
+		t.Fatal("one failure after demotion must demote again")
+	}
+}
+
+func TestFailover_GetQuotes(t *testing.T) {
+	primary := &testutil.FakeBatchClient{BatchErr: &price.RateLimitError{}}
+	secondary := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		btcUSD:                      100,
+		{ID: "ethereum", VS: "usd"}: 10,
+	}}}
+	tertiary := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{{ID: "solana", VS: "usd"}: 1}}
+	f, err := client.NewFailover([]string{"coingecko", "binance", "kraken"},
+		map[string]price.PriceClient{"coingecko": primary, "binance": secondary, "kraken": tertiary},
+		client.DefaultFailoverConfig(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotes, err := f.GetQuotes(context.Background(), []string{"bitcoin", "ethereum", "solana"}, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 3 || quotes[price.Pair{ID: "ethereum", VS: "usd"}].Source != "binance" ||
+		quotes[price.Pair{ID: "solana", VS: "usd"}].Source != "kraken" {
+		t.Fatalf("unexpected quotes: %+v", quotes)
+	}
+	if tertiary.Calls() != 1 {
+		t.Fatalf("kraken must be asked only for the remaining pair, got %d calls", tertiary.Calls())
+	}
+}
@@ -0,0 +1,250 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+// putEntry кладёт в кэш цену, полученную age назад.
+func putEntry(t *testing.T, c *testutil.FakeCache, id, vs string, v float64, age time.Duration) {
+	t.Helper()
+	val := fmt.Sprintf(`{"price":%v,"fetched_at":%q}`, v, time.Now().Add(-age).Format(time.RFC3339Nano))
+	if err := c.Set(context.Background(), "price:"+id+":"+vs, []byte(val)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachedPriceClient_StaleWhileRevalidate(t *testing.T) {
+	backend := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 200},
+		Delay:     20 * time.Millisecond,
+	}
+	c := testutil.NewFakeCache()
+	putEntry(t, c, "bitcoin", "usd", 100, 2*time.Second)
+	m := testutil.NewRecordingMetrics()
+	cached := client.NewCachedPriceClient(backend, c, m,
+		client.WithFreshness(client.Freshness{FreshFor: time.Second, StaleFor: time.Minute}))
+
+	start := time.Now()
+	q, err := cached.GetQuote(context.Background(), "bitcoin", "usd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Price != 100 || !q.Stale {
+		t.Fatalf("want stale 100, got %+v", q)
+	}
+	if time.Since(start) >= backend.Delay {
+		t.Fatalf("stale value must be served without waiting for backend")
+	}
+	if m.Count("stale:revalidate") != 1 {
+		t.Fatalf("stale serve not recorded")
+	}
+
+	// Фоновое обновление кладёт свежую цену в кэш.
+	deadline := time.Now().Add(time.Second)
+	for {
+		q, err = cached.GetQuote(context.Background(), "bitcoin", "usd")
+		if err == nil && q.Price == 200 && !q.Stale {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background refresh did not update cache, last: %+v, %v", q, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("want exactly 1 background backend call, got %d", backend.Calls())
+	}
+}
+
+func TestCachedPriceClient_ServeStaleOnError(t *testing.T) {
+	unavailable := fmt.Errorf("%w: boom", price.ErrUpstreamUnavailable)
+	tests := []struct {
+		name      string
+		age       time.Duration
+		backend   error
+		wantStale bool
+	}{
+		{name: "within max stale", age: 30 * time.Second, backend: unavailable, wantStale: true},
+		{name: "beyond max stale", age: 2 * time.Minute, backend: unavailable},
+		{name: "permanent error is not masked", age: 30 * time.Second, backend: price.ErrUnknownID},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &testutil.FakePriceClient{
+				Errors: map[testutil.Key]error{{ID: "bitcoin", VS: "usd"}: tc.backend},
+			}
+			c := testutil.NewFakeCache()
+			putEntry(t, c, "bitcoin", "usd", 100, tc.age)
+			cached := client.NewCachedPriceClient(backend, c, nil,
+				client.WithFreshness(client.Freshness{FreshFor: time.Second, StaleFor: time.Second, MaxStale: time.Minute}))
+
+			q, err := cached.GetQuote(context.Background(), "bitcoin", "usd")
+			if !tc.wantStale {
+				if !errors.Is(err, tc.backend) {
+					t.Fatalf("expected backend error, got %+v, %v", q, err)
+				}
+				return
+			}
+			if err != nil || q.Price != 100 || !q.Stale {
+				t.Fatalf("want stale 100, got %+v, %v", q, err)
+			}
+			if age := q.Age(time.Now()); age < tc.age || age > tc.age+time.Second {
+				t.Fatalf("unexpected age %v", age)
+			}
+
+			got, err := cached.GetPrices(context.Background(), []string{"bitcoin"}, []string{"usd"})
+			if err == nil || got["bitcoin"]["usd"] != 100 {
+				t.Fatalf("GetPrices: want stale 100 with error, got %v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestCachedPriceClient_LegacyEntry(t *testing.T) {
+	backend := &testutil.FakePriceClient{}
+	c := testutil.NewFakeCache()
+	_ = c.Set(context.Background(), "price:bitcoin:usd", []byte("123.5"))
+	cached := client.NewCachedPriceClient(backend, c, nil)
+
+	v, err := cached.GetPrice(context.Background(), "bitcoin", "usd")
+	if err != nil || v != 123.5 {
+		t.Fatalf("want legacy 123.5, got %v, %v", v, err)
+	}
+	if backend.Calls() != 0 {
+		t.Fatalf("legacy entry must be served from cache")
+	}
+}
+
+func TestCachedPriceClient_Envelope(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100,"last_updated_at":1700000000}}`))
+	}))
+	defer ts.Close()
+	cg := client.NewCoinGeckoClient(5 * time.Second)
+	cg.SetBaseURL(ts.URL)
+
+	c := testutil.NewFakeCache()
+	cached := client.NewCachedPriceClient(cg, c, nil)
+	ctx := context.Background()
+
+	first, err := cached.GetQuote(ctx, "bitcoin", "usd")
+	if err != nil || first.Cached {
+		t.Fatalf("first call must go to backend, got %+v, %v", first, err)
+	}
+
+	raw, err := c.Get(ctx, "price:bitcoin:usd")
+	if err != nil {
+		t.Fatalf("entry not stored: %v", err)
+	}
+	var env map[string]any
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		t.Fatalf("entry is not a JSON object: %s", raw)
+	}
+	for _, k := range []string{"v", "price", "fetched_at", "source", "upstream_updated_at"} {
+		if _, ok := env[k]; !ok {
+			t.Fatalf("envelope has no %q: %s", k, raw)
+		}
+	}
+
+	q, err := cached.GetQuote(ctx, "bitcoin", "usd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Cached || q.Price != 100 || q.Source != client.CoinGeckoName {
+		t.Fatalf("unexpected cached quote: %+v", q)
+	}
+	if !q.FetchedAt.Equal(first.FetchedAt) || !q.UpstreamUpdatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("timestamps not preserved: %+v", q)
+	}
+}
+
+func TestCachedPriceClient_FutureEnvelopeIsMiss(t *testing.T) {
+	backend := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 200},
+	}
+	c := testutil.NewFakeCache()
+	_ = c.Set(context.Background(), "price:bitcoin:usd", []byte(`{"v":99,"price":100}`))
+	cached := client.NewCachedPriceClient(backend, c, nil)
+
+	v, err := cached.GetPrice(context.Background(), "bitcoin", "usd")
+	if err != nil || v != 200 {
+		t.Fatalf("want backend 200, got %v, %v", v, err)
+	}
+}
+
+// Пары разных классов хранятся с TTL своего класса; batch пишется одним MSet на TTL.
+func TestCachedPriceClient_ClassFreshness(t *testing.T) {
+	backend := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}:  100,
+			{ID: "ethereum", VS: "usd"}: 10,
+			{ID: "usd", VS: "rub"}:      90,
+		},
+	}}
+	c := testutil.NewFakeCache()
+	cached := client.NewCachedPriceClient(backend, c, nil,
+		client.WithFreshness(client.Freshness{FreshFor: time.Minute}),
+		client.WithClassFreshness(map[asset.Class]client.Freshness{
+			asset.Fiat: {FreshFor: time.Hour, MaxStale: 24 * time.Hour},
+		}))
+
+	pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}, {ID: "ethereum", VS: "usd"}, {ID: "usd", VS: "rub"}}
+	if _, err := cached.GetPairs(context.Background(), pairs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.TTL("price:bitcoin:usd"); got != time.Minute {
+		t.Fatalf("crypto pair: want TTL 1m, got %s", got)
+	}
+	if got := c.TTL("price:usd:rub"); got != 24*time.Hour {
+		t.Fatalf("fiat pair: want TTL 24h, got %s", got)
+	}
+	if got := c.MSets(); got != 2 {
+		t.Fatalf("want one MSet per TTL (2), got %d", got)
+	}
+
+	// через 2 минуты крипта уже не свежая, а фиатный курс — свежий
+	putEntry(t, c, "usd", "rub", 91, 2*time.Minute)
+	putEntry(t, c, "bitcoin", "usd", 101, 2*time.Minute)
+	q, err := cached.GetQuote(context.Background(), "usd", "rub")
+	if err != nil || !q.Cached || q.Price != 91 {
+		t.Fatalf("fiat pair must be served from cache, got %+v, %v", q, err)
+	}
+	q, err = cached.GetQuote(context.Background(), "bitcoin", "usd")
+	if err != nil || q.Cached {
+		t.Fatalf("crypto pair must be refetched, got %+v, %v", q, err)
+	}
+}
+
+func TestPairClass(t *testing.T) {
+	tests := []struct {
+		pair price.Pair
+		want asset.Class
+		ok   bool
+	}{
+		{price.Pair{ID: "bitcoin", VS: "usd"}, asset.Crypto, true},
+		{price.Pair{ID: "usd", VS: "bitcoin"}, asset.Crypto, true},
+		{price.Pair{ID: "tether", VS: "usd"}, asset.Stablecoin, true},
+		{price.Pair{ID: "usd", VS: "rub"}, asset.Fiat, true},
+		{price.Pair{ID: "notacoin", VS: "usd"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := client.PairClass(tt.pair)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("%s: want %q, %v; got %q, %v", tt.pair, tt.want, tt.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-01-05">
+			<Cube currency="USD" rate="1.0921"/>
+			<Cube currency="JPY" rate="158.08"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+// cbrDaily — XML_daily.asp в windows-1251: имя валюты «Доллар США» закодировано однобайтно.
+func cbrDaily(date string) []byte {
+	body := []byte(`<?xml version="1.0" encoding="windows-1251"?><ValCurs Date="` + date + `" name="Foreign Currency Market">`)
+	body = append(body, `<Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal><Name>`...)
+	body = append(body, 0xC4, 0xEE, 0xEB, 0xEB, 0xE0, 0xF0, ' ', 0xD1, 0xD8, 0xC0)
+	body = append(body, `</Name><Value>90,6537</Value></Valute>`...)
+	body = append(body, `<Valute ID="R01375"><CharCode>CNY</CharCode><Nominal>10</Nominal><Value>126,9800</Value></Valute></ValCurs>`...)
+	return body
+}
+
+// fxServers поднимает ECB и CBR и возвращает клиент, настроенный на них, и счётчики запросов.
+func fxServers(t *testing.T, ecbStatus int) (*client.FXClient, *atomic.Int64, *atomic.Int64) {
+	t.Helper()
+	var ecbCalls, cbrCalls atomic.Int64
+	ecb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ecbCalls.Add(1)
+		if ecbStatus != http.StatusOK {
+			w.WriteHeader(ecbStatus)
+			return
+		}
+		_, _ = w.Write([]byte(ecbDaily))
+	}))
+	t.Cleanup(ecb.Close)
+	cbr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cbrCalls.Add(1)
+		_, _ = w.Write(cbrDaily("06.01.2024"))
+	}))
+	t.Cleanup(cbr.Close)
+
+	c := client.NewFXClient(5 * time.Second)
+	c.SetFeedURL(client.ECBName, ecb.URL)
+	c.SetFeedURL(client.CBRName, cbr.URL)
+	return c, &ecbCalls, &cbrCalls
+}
+
+func TestFXClient_GetQuote(t *testing.T) {
+	c, ecbCalls, cbrCalls := fxServers(t, http.StatusOK)
+	// пятница после публикации ЕЦБ: обе таблицы актуальны до понедельника
+	c.SetClock(func() time.Time { return time.Date(2024, 1, 5, 17, 0, 0, 0, time.UTC) })
+	ctx := context.Background()
+
+	tests := []struct {
+		id, vs     string
+		want       float64
+		wantSource string
+		wantDate   string
+	}{
+		{id: "eur", vs: "usd", want: 1.0921, wantSource: client.ECBName, wantDate: "2024-01-05"},
+		{id: "usd", vs: "jpy", want: 158.08 / 1.0921, wantSource: client.ECBName, wantDate: "2024-01-05"},
+		{id: "usd", vs: "rub", want: 90.6537, wantSource: client.CBRName, wantDate: "2024-01-06"},
+		{id: "cny", vs: "rub", want: 12.698, wantSource: client.CBRName, wantDate: "2024-01-06"},
+	}
+	for _, tc := range tests {
+		q, err := c.GetQuote(ctx, tc.id, tc.vs)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tc.id, tc.vs, err)
+		}
+		if diff := q.Price - tc.want; diff > 1e-9 || diff < -1e-9 {
+			t.Fatalf("%s/%s: want %v, got %v", tc.id, tc.vs, tc.want, q.Price)
+		}
+		if q.Source != tc.wantSource || q.UpstreamUpdatedAt.Format("2006-01-02") != tc.wantDate {
+			t.Fatalf("%s/%s: unexpected quote metadata: %+v", tc.id, tc.vs, q)
+		}
+	}
+
+	if _, err := c.GetQuote(ctx, "xyz", "rub"); !errors.Is(err, price.ErrUnknownID) {
+		t.Fatalf("want ErrUnknownID, got %v", err)
+	}
+	if _, err := c.GetQuote(ctx, "usd", "xyz"); !errors.Is(err, price.ErrUnknownVS) {
+		t.Fatalf("want ErrUnknownVS, got %v", err)
+	}
+	if ecbCalls.Load() != 1 || cbrCalls.Load() != 1 {
+		t.Fatalf("tables must be fetched once per publication, got ecb=%d cbr=%d", ecbCalls.Load(), cbrCalls.Load())
+	}
+}
+
+func TestFXClient_BusinessDayRefetch(t *testing.T) {
+	c, ecbCalls, cbrCalls := fxServers(t, http.StatusOK)
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 5, 17, 0, 0, 0, time.UTC) // пятница
+	c.SetClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	})
+	setNow := func(t time.Time) {
+		mu.Lock()
+		now = t
+		mu.Unlock()
+	}
+	ctx := context.Background()
+	get := func() {
+		t.Helper()
+		if _, err := c.GetQuotes(ctx, []string{"usd"}, []string{"rub", "eur"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get()
+	// выходные: новых таблиц не ожидается — ЕЦБ от пятницы, ЦБ РФ на субботу
+	setNow(time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC))
+	get()
+	if ecbCalls.Load() != 1 || cbrCalls.Load() != 1 {
+		t.Fatalf("no refetch expected over the weekend, got ecb=%d cbr=%d", ecbCalls.Load(), cbrCalls.Load())
+	}
+
+	// понедельник 13:00 UTC: ЦБ РФ уже опубликовал курс на вторник (15:30 МСК), ЕЦБ ещё нет
+	setNow(time.Date(2024, 1, 8, 13, 0, 0, 0, time.UTC))
+	get()
+	if ecbCalls.Load() != 1 || cbrCalls.Load() != 2 {
+		t.Fatalf("only cbr must be refetched, got ecb=%d cbr=%d", ecbCalls.Load(), cbrCalls.Load())
+	}
+
+	// ЕЦБ опубликовал понедельник
+	setNow(time.Date(2024, 1, 8, 15, 30, 0, 0, time.UTC))
+	get()
+	if ecbCalls.Load() != 2 {
+		t.Fatalf("ecb must be refetched after monday publication, got %d", ecbCalls.Load())
+	}
+	// сервер всё ещё отдаёт пятницу (праздник или задержка): повтор — не раньше чем через retry interval
+	get()
+	if ecbCalls.Load() != 2 {
+		t.Fatalf("missing publication must not be refetched on every call, got %d", ecbCalls.Load())
+	}
+}
+
+func TestFXClient_FetchedAtIsCallTime(t *testing.T) {
+	c, ecbCalls, _ := fxServers(t, http.StatusOK)
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 5, 17, 0, 0, 0, time.UTC)
+	c.SetClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetQuote(ctx, "eur", "usd"); err != nil {
+		t.Fatal(err)
+	}
+	// таблица та же, но котировка отдаётся позже: FetchedAt — момент вызова
+	mu.Lock()
+	now = now.Add(time.Hour)
+	later := now
+	mu.Unlock()
+	q, err := c.GetQuote(ctx, "eur", "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ecbCalls.Load() != 1 {
+		t.Fatalf("cached table expected, got %d ecb calls", ecbCalls.Load())
+	}
+	if !q.FetchedAt.Equal(later) {
+		t.Fatalf("want FetchedAt %v, got %v", later, q.FetchedAt)
+	}
+	if q.UpstreamUpdatedAt.Format("2006-01-02") != "2024-01-05" {
+		t.Fatalf("want table date in UpstreamUpdatedAt, got %v", q.UpstreamUpdatedAt)
+	}
+}
+
+func TestFXClient_FeedUnavailable(t *testing.T) {
+	c, _, _ := fxServers(t, http.StatusServiceUnavailable)
+	ctx := context.Background()
+
+	if _, err := c.GetQuote(ctx, "eur", "usd"); !errors.Is(err, price.ErrUpstreamUnavailable) {
+		t.Fatalf("want ErrUpstreamUnavailable without ecb, got %v", err)
+	}
+	// usd/rub есть в таблице ЦБ РФ — недоступность ЕЦБ ему не мешает
+	if got, err := c.GetPrice(ctx, "usd", "rub"); err != nil || got != 90.6537 {
+		t.Fatalf("want usd/rub from cbr, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,136 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+func TestKrakenClient_GetQuote(t *testing.T) {
+	tests := []struct {
+		name      string
+		id, vs    string
+		body      string
+		status    int
+		wantPair  string
+		want      float64
+		wantVol   float64
+		wantErrIs error
+	}{
+		{
+			name:     "success with xbt symbol",
+			id:       "bitcoin",
+			vs:       "usd",
+			body:     `{"error":[],"result":{"XXBTZUSD":{"a":["43001.0","1","1.000"],"c":["43000.10000","0.00100000"],"v":["812.5","1905.25"]}}}`,
+			wantPair: "XBTUSD",
+			want:     43000.1,
+			wantVol:  1905.25,
+		},
+		{
+			name:      "unknown asset pair",
+			id:        "bitcoin",
+			vs:        "rub",
+			body:      `{"error":["EQuery:Unknown asset pair"]}`,
+			wantPair:  "XBTRUB",
+			wantErrIs: price.ErrUnknownVS,
+		},
+		{
+			name:      "api rate limit",
+			id:        "ethereum",
+			vs:        "usd",
+			body:      `{"error":["EAPI:Rate limit exceeded"]}`,
+			wantPair:  "ETHUSD",
+			wantErrIs: price.ErrRateLimited,
+		},
+		{
+			name:      "service unavailable",
+			id:        "ethereum",
+			vs:        "usd",
+			body:      `{"error":["EService:Unavailable"]}`,
+			wantPair:  "ETHUSD",
+			wantErrIs: price.ErrUpstreamUnavailable,
+		},
+		{
+			name:      "http 5xx",
+			id:        "ethereum",
+			vs:        "usd",
+			status:    http.StatusInternalServerError,
+			wantPair:  "ETHUSD",
+			wantErrIs: price.ErrUpstreamUnavailable,
+		},
+		{
+			name:      "empty result",
+			id:        "ethereum",
+			vs:        "usd",
+			body:      `{"error":[],"result":{}}`,
+			wantPair:  "ETHUSD",
+			wantErrIs: price.ErrBadPayload,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath, gotPair string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotPair = r.URL.Path, r.URL.Query().Get("pair")
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			c := client.NewKrakenClient(5 * time.Second)
+			c.SetBaseURL(ts.URL)
+
+			q, err := c.GetQuote(context.Background(), tc.id, tc.vs)
+			if gotPath != "/0/public/Ticker" || gotPair != tc.wantPair {
+				t.Fatalf("unexpected request: path=%q pair=%q", gotPath, gotPair)
+			}
+			if tc.wantErrIs != nil {
+				if !errors.Is(err, tc.wantErrIs) {
+					t.Fatalf("expected errors.Is(err, %v), got: %v", tc.wantErrIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if q.Price != tc.want || q.Volume != tc.wantVol || q.Source != client.KrakenName {
+				t.Fatalf("unexpected quote: %+v", q)
+			}
+		})
+	}
+}
+
+func TestKrakenClient_SetSymbols(t *testing.T) {
+	var gotPair string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPair = r.URL.Query().Get("pair")
+		_, _ = w.Write([]byte(`{"error":[],"result":{"WIFUSD":{"c":["2.5","10"]}}}`))
+	}))
+	defer ts.Close()
+
+	c := client.NewKrakenClient(5 * time.Second)
+	c.SetBaseURL(ts.URL)
+
+	// id вне реестра asset становится доступен через карту тикеров
+	syms := client.KrakenSymbols()
+	syms["dogwifcoin"] = "WIF"
+	c.SetSymbols(syms)
+
+	got, err := c.GetPrice(context.Background(), "dogwifcoin", "usd")
+	if err != nil || got != 2.5 {
+		t.Fatalf("want 2.5, got %v, %v", got, err)
+	}
+	if gotPair != "WIFUSD" {
+		t.Fatalf("want pair WIFUSD, got %q", gotPair)
+	}
+}
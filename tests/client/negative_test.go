@@ -0,0 +1,119 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func TestCachedPriceClient_NegativeCaching(t *testing.T) {
+	backend := &testutil.FakePriceClient{
+		Errors: map[testutil.Key]error{
+			{ID: "nocoin", VS: "usd"}: fmt.Errorf("%w: nocoin", price.ErrUnknownID),
+		},
+	}
+	m := testutil.NewRecordingMetrics()
+	c := testutil.NewFakeCache()
+	cached := client.NewCachedPriceClient(backend, c, m, client.WithNegativeTTL(time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cached.GetPrice(ctx, "nocoin", "usd"); !errors.Is(err, price.ErrUnknownID) {
+			t.Fatalf("call %d: want ErrUnknownID, got %v", i, err)
+		}
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("unknown pair must be fetched once, got %d backend calls", backend.Calls())
+	}
+	if got := m.Count("negative_hit"); got != 2 {
+		t.Fatalf("want 2 negative hits, got %d", got)
+	}
+	if got := c.TTL("price:nocoin:usd"); got != time.Minute {
+		t.Fatalf("negative entry must use its own TTL, got %s", got)
+	}
+}
+
+func TestCachedPriceClient_NegativeCachingBatch(t *testing.T) {
+	backend := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+	}}
+	cached := client.NewCachedPriceClient(backend, testutil.NewFakeCache(), nil, client.WithNegativeTTL(time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		got, err := cached.GetPrices(ctx, []string{"bitcoin"}, []string{"usd", "xyz"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got["bitcoin"]["usd"] != 100 || len(got["bitcoin"]) != 1 {
+			t.Fatalf("unexpected prices: %v", got)
+		}
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("second batch must be served from cache, got %d backend calls", backend.Calls())
+	}
+	if _, err := cached.GetPrice(ctx, "bitcoin", "xyz"); !errors.Is(err, price.ErrUnknownVS) {
+		t.Fatalf("want ErrUnknownVS from negative cache, got %v", err)
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("negative entry must answer GetPrice, got %d backend calls", backend.Calls())
+	}
+}
+
+// Неизвестная пара запоминается, даже если соседняя пара того же batch упала,
+// а попадание в negative cache отдаёт её собственную ошибку.
+func TestCachedPriceClient_NegativeCachingMixedBatch(t *testing.T) {
+	backend := &testutil.FakePriceClient{
+		Errors: map[testutil.Key]error{
+			{ID: "nocoin", VS: "usd"}:  fmt.Errorf("%w: nocoin", price.ErrUnknownID),
+			{ID: "bitcoin", VS: "usd"}: &price.StatusError{StatusCode: 503, Status: "503 Service Unavailable"},
+		},
+	}
+	m := testutil.NewRecordingMetrics()
+	cached := client.NewCachedPriceClient(backend, testutil.NewFakeCache(), m, client.WithNegativeTTL(time.Minute))
+	pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}, {ID: "nocoin", VS: "usd"}}
+
+	for i := 0; i < 2; i++ {
+		_, err := cached.GetPairs(context.Background(), pairs)
+		if got := price.ErrorFor(err, pairs[0]); !errors.Is(got, price.ErrUpstreamUnavailable) {
+			t.Fatalf("call %d: bitcoin->usd: want ErrUpstreamUnavailable, got %v", i, got)
+		}
+		if got := price.ErrorFor(err, pairs[1]); !errors.Is(got, price.ErrUnknownID) || errors.Is(got, price.ErrUpstreamUnavailable) {
+			t.Fatalf("call %d: nocoin->usd: want only ErrUnknownID, got %v", i, got)
+		}
+	}
+	if backend.Calls() != 3 {
+		t.Fatalf("nocoin must be fetched once and bitcoin twice, got %d backend calls", backend.Calls())
+	}
+	if got := m.Count("negative_hit"); got != 1 {
+		t.Fatalf("want 1 negative hit, got %d", got)
+	}
+}
+
+func TestCachedPriceClient_TransientErrorsNotNegativeCached(t *testing.T) {
+	backend := &testutil.FakePriceClient{
+		Errors: map[testutil.Key]error{
+			{ID: "bitcoin", VS: "usd"}: &price.StatusError{StatusCode: 503, Status: "503 Service Unavailable"},
+		},
+	}
+	c := testutil.NewFakeCache()
+	cached := client.NewCachedPriceClient(backend, c, nil, client.WithNegativeTTL(time.Minute))
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.GetPrice(context.Background(), "bitcoin", "usd"); !errors.Is(err, price.ErrUpstreamUnavailable) {
+			t.Fatalf("want ErrUpstreamUnavailable, got %v", err)
+		}
+	}
+	if backend.Calls() != 2 {
+		t.Fatalf("transient errors must not be cached, got %d backend calls", backend.Calls())
+	}
+	if c.Len() != 0 {
+		t.Fatalf("nothing must be cached, got %d entries", c.Len())
+	}
+}
@@ -0,0 +1,101 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func newLimitedFake() *testutil.FakePriceClient {
+	return &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+	}
+}
+
+func TestRateLimitedClient_Reject(t *testing.T) {
+	fake := newLimitedFake()
+	m := testutil.NewRecordingMetrics()
+	c := client.NewRateLimitedClient("test", fake, client.RateLimit{Rate: 1, Burst: 2}, m)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetPrice(ctx, "bitcoin", "usd"); err != nil {
+			t.Fatalf("call %d within burst: %v", i, err)
+		}
+	}
+
+	_, err := c.GetPrice(ctx, "bitcoin", "usd")
+	var rl *price.RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter <= 0 {
+		t.Fatalf("expected *price.RateLimitError with RetryAfter, got %v", err)
+	}
+	if fake.Calls() != 2 {
+		t.Fatalf("rejected call must not reach backend, calls=%d", fake.Calls())
+	}
+	if m.Count("ratelimit_rejected:test") != 1 {
+		t.Fatalf("rejection not recorded")
+	}
+}
+
+func TestRateLimitedClient_Wait(t *testing.T) {
+	fake := newLimitedFake()
+	c := client.NewRateLimitedClient("test", fake, client.RateLimit{Rate: 20, Burst: 1, Wait: true}, nil)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetPrice(ctx, "bitcoin", "usd"); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	// 1 токен сразу, ещё 2 — по 50ms.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("calls were not throttled: %v", elapsed)
+	}
+}
+
+func TestRateLimitedClient_WaitRespectsDeadline(t *testing.T) {
+	fake := newLimitedFake()
+	c := client.NewRateLimitedClient("test", fake, client.RateLimit{Rate: 1, Burst: 1, Wait: true}, nil)
+
+	if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetPrice(ctx, "bitcoin", "usd")
+	if !errors.Is(err, price.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("limiter waited %v although token could not arrive before deadline", elapsed)
+	}
+}
+
+func TestRateLimitedClient_SetLimit(t *testing.T) {
+	fake := newLimitedFake()
+	c := client.NewRateLimitedClient("test", fake, client.RateLimit{Rate: 1, Burst: 5}, nil)
+	ctx := context.Background()
+
+	// новый burst меньше накопленных токенов — лишние отбрасываются
+	c.SetLimit(client.RateLimit{Rate: 1, Burst: 1})
+	if _, err := c.GetPrice(ctx, "bitcoin", "usd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetPrice(ctx, "bitcoin", "usd"); !errors.Is(err, price.ErrRateLimited) {
+		t.Fatalf("want rate limited after burst shrink, got %v", err)
+	}
+
+	c.SetLimit(client.RateLimit{Rate: 1000, Burst: 1})
+	time.Sleep(5 * time.Millisecond)
+	if _, err := c.GetPrice(ctx, "bitcoin", "usd"); err != nil {
+		t.Fatalf("raised rate must refill quickly: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func fastRetryPolicy() client.RetryPolicy {
+	return client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Jitter: 0.5, MaxRetryAfter: 2 * time.Second}
+}
+
+// newFlakyServer отвечает статусами statuses по очереди, затем — успехом.
+func newFlakyServer(t *testing.T, calls *atomic.Int32, retryAfter string, statuses ...int) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100}}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestCoinGeckoClient_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+		wantErrIs error
+		wantRetry string
+	}{
+		{name: "recovers after 5xx", statuses: []int{503, 502}, wantCalls: 3, wantRetry: "retry:coingecko:unavailable"},
+		{name: "recovers after 429", statuses: []int{429}, wantCalls: 2, wantRetry: "retry:coingecko:rate_limited"},
+		{name: "gives up after max attempts", statuses: []int{500, 500, 500, 500}, wantCalls: 3, wantErr: true, wantErrIs: price.ErrUpstreamUnavailable},
+		{name: "4xx is not retried", statuses: []int{400}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := newFlakyServer(t, &calls, "", tc.statuses...)
+			m := testutil.NewRecordingMetrics()
+
+			c := client.NewCoinGeckoClient(time.Second)
+			c.SetBaseURL(ts.URL)
+			c.SetRetryPolicy(fastRetryPolicy())
+			c.SetMetrics(m)
+
+			got, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+			if calls.Load() != tc.wantCalls {
+				t.Fatalf("want %d upstream calls, got %d", tc.wantCalls, calls.Load())
+			}
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if tc.wantErrIs != nil && !errors.Is(err, tc.wantErrIs) {
+					t.Fatalf("expected errors.Is(err, %v), got %v", tc.wantErrIs, err)
+				}
+				return
+			}
+			if err != nil || got != 100 {
+				t.Fatalf("want 100, nil; got %v, %v", got, err)
+			}
+			if m.Count(tc.wantRetry) == 0 {
+				t.Fatalf("expected %q to be recorded", tc.wantRetry)
+			}
+		})
+	}
+}
+
+// Каждая попытка — отдельный запрос к CoinGecko и расходует свой токен лимита.
+func TestCoinGeckoClient_RetriesSpendRateLimitTokens(t *testing.T) {
+	newClient := func(ts *httptest.Server, burst int) *client.RateLimitedClient {
+		cg := client.NewCoinGeckoClient(time.Second)
+		cg.SetBaseURL(ts.URL)
+		cg.SetRetryPolicy(fastRetryPolicy())
+		limited := client.NewRateLimitedClient(client.CoinGeckoName, cg, client.RateLimit{Rate: 0.001, Burst: burst}, nil)
+		cg.SetRetryLimiter(limited)
+		return limited
+	}
+
+	t.Run("one token per attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		c := newClient(newFlakyServer(t, &calls, "", 503, 503), 3)
+
+		if got, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err != nil || got != 100 {
+			t.Fatalf("want 100, nil; got %v, %v", got, err)
+		}
+		if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); !errors.Is(err, price.ErrRateLimited) {
+			t.Fatalf("three attempts must use up the burst, got %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Fatalf("want 3 upstream calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("no retry without a token", func(t *testing.T) {
+		var calls atomic.Int32
+		c := newClient(newFlakyServer(t, &calls, "", 503, 503, 503), 2)
+
+		_, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+		if !errors.Is(err, price.ErrUpstreamUnavailable) {
+			t.Fatalf("want the last upstream error, got %v", err)
+		}
+		if calls.Load() != 2 {
+			t.Fatalf("want 2 upstream calls within the burst, got %d", calls.Load())
+		}
+	})
+}
+
+func TestCoinGeckoClient_RetryAfter(t *testing.T) {
+	t.Run("honours header", func(t *testing.T) {
+		var calls atomic.Int32
+		ts := newFlakyServer(t, &calls, "1", http.StatusTooManyRequests)
+		c := client.NewCoinGeckoClient(time.Second)
+		c.SetBaseURL(ts.URL)
+		c.SetRetryPolicy(fastRetryPolicy())
+
+		start := time.Now()
+		if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Fatalf("retried after %v, Retry-After not honoured", elapsed)
+		}
+	})
+
+	t.Run("does not wait past deadline", func(t *testing.T) {
+		var calls atomic.Int32
+		ts := newFlakyServer(t, &calls, "30", http.StatusTooManyRequests)
+		c := client.NewCoinGeckoClient(time.Second)
+		c.SetBaseURL(ts.URL)
+		c.SetRetryPolicy(fastRetryPolicy())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := c.GetPrice(ctx, "bitcoin", "usd")
+		if !errors.Is(err, price.ErrRateLimited) {
+			t.Fatalf("expected rate limited error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+			t.Fatalf("client waited %v despite deadline", elapsed)
+		}
+		if calls.Load() != 1 {
+			t.Fatalf("want 1 upstream call, got %d", calls.Load())
+		}
+	})
+
+	t.Run("gives up when Retry-After exceeds the cap", func(t *testing.T) {
+		var calls atomic.Int32
+		ts := newFlakyServer(t, &calls, "30", http.StatusTooManyRequests)
+		c := client.NewCoinGeckoClient(time.Second)
+		c.SetBaseURL(ts.URL)
+		c.SetRetryPolicy(fastRetryPolicy())
+
+		start := time.Now()
+		_, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+		var rl *price.RateLimitError
+		if !errors.As(err, &rl) || rl.RetryAfter != 30*time.Second {
+			t.Fatalf("expected rate limit error with Retry-After 30s, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+			t.Fatalf("client waited %v past MaxRetryAfter", elapsed)
+		}
+		if calls.Load() != 1 {
+			t.Fatalf("want 1 upstream call, got %d", calls.Load())
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+)
+
+func mustRoutes(t *testing.T, rules ...string) []client.Route {
+	t.Helper()
+	routes := make([]client.Route, len(rules))
+	for i, s := range rules {
+		r, err := client.ParseRoute(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routes[i] = r
+	}
+	return routes
+}
+
+func TestParseRoute(t *testing.T) {
+	r, err := client.ParseRoute(" Bitcoin/* = binance, coingecko ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != "bitcoin" || r.VS != "*" || len(r.Providers) != 2 || r.Providers[1] != "coingecko" {
+		t.Fatalf("unexpected route: %+v", r)
+	}
+	if r.String() != "bitcoin/*=binance,coingecko" {
+		t.Fatalf("unexpected string: %q", r.String())
+	}
+	for _, bad := range []string{"", "bitcoin=binance", "bitcoin/usd", "bitcoin/usd=", "/usd=binance"} {
+		if _, err := client.ParseRoute(bad); err == nil {
+			t.Fatalf("want error for %q", bad)
+		}
+	}
+}
+
+func TestRouter_Routes(t *testing.T) {
+	coingecko := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		{ID: "ethereum", VS: "usd"}: 10,
+		{ID: "tether", VS: "usd"}:   1,
+	}}
+	binance := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		{ID: "bitcoin", VS: "usd"}: 100,
+	}}
+	fx := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		{ID: "usd", VS: "rub"}: 90,
+	}}
+	m := testutil.NewRecordingMetrics()
+	r, err := client.NewRouter(map[string]price.PriceClient{
+		"coingecko": coingecko, "binance": binance, "fx": fx,
+	}, mustRoutes(t, "bitcoin/*=binance", "fiat/fiat=fx", "stablecoin/usd=coingecko", "*/*=coingecko"), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		id, vs     string
+		want       float64
+		wantSource string
+	}{
+		{id: "bitcoin", vs: "usd", want: 100, wantSource: "binance"},   // по id
+		{id: "usd", vs: "rub", want: 90, wantSource: "fx"},             // по классу
+		{id: "tether", vs: "usd", want: 1, wantSource: "coingecko"},    // класс + vs
+		{id: "ethereum", vs: "usd", want: 10, wantSource: "coingecko"}, // wildcard
+	}
+	for _, tc := range tests {
+		q, err := r.GetQuote(ctx, tc.id, tc.vs)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tc.id, tc.vs, err)
+		}
+		if q.Price != tc.want || q.Source != tc.wantSource {
+			t.Fatalf("%s/%s: want %v from %s, got %+v", tc.id, tc.vs, tc.want, tc.wantSource, q)
+		}
+	}
+	if m.Count("route:binance:served") != 1 || m.Count("route:coingecko:served") != 2 {
+		t.Fatalf("served pairs must be counted per provider")
+	}
+}
+
+func TestRouter_FallbackOnlyForUnknownPairs(t *testing.T) {
+	binance := &testutil.FakePriceClient{Errors: map[testutil.Key]error{
+		{ID: "bitcoin", VS: "rub"}: fmt.Errorf("%w: no market", price.ErrUnknownVS),
+		{ID: "bitcoin", VS: "usd"}: &price.RateLimitError{},
+	}}
+	coingecko := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		{ID: "bitcoin", VS: "rub"}: 9000,
+		{ID: "bitcoin", VS: "usd"}: 100,
+	}}
+	m := testutil.NewRecordingMetrics()
+	r, err := client.NewRouter(map[string]price.PriceClient{"binance": binance, "coingecko": coingecko},
+		mustRoutes(t, "crypto/*=binance,coingecko"), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	q, err := r.GetQuote(ctx, "bitcoin", "rub")
+	if err != nil || q.Price != 9000 || q.Source != "coingecko" {
+		t.Fatalf("unknown pair must fall back to coingecko, got %+v, %v", q, err)
+	}
+	if m.Count("route:binance:fallback") != 1 {
+		t.Fatalf("fallback not recorded")
+	}
+
+	// временная ошибка провайдера — не повод спрашивать следующего (см. FailoverClient)
+	if _, err := r.GetQuote(ctx, "bitcoin", "usd"); !errors.Is(err, price.ErrRateLimited) {
+		t.Fatalf("want rate limited error, got %v", err)
+	}
+	if coingecko.Calls() != 1 {
+		t.Fatalf("coingecko must not be asked on binance errors, got %d calls", coingecko.Calls())
+	}
+
+	if _, err := r.GetQuote(ctx, "usd", "rub"); !errors.Is(err, price.ErrUnknownID) {
+		t.Fatalf("pair without a route must be unknown, got %v", err)
+	}
+}
+
+func TestRouter_GetQuotes(t *testing.T) {
+	coingecko := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{
+		{ID: "bitcoin", VS: "rub"}:  9000,
+		{ID: "ethereum", VS: "usd"}: 10,
+		{ID: "ethereum", VS: "rub"}: 900,
+	}}
+	binance := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Errors:    map[testutil.Key]error{{ID: "bitcoin", VS: "rub"}: price.ErrUnknownVS},
+	}
+	fx := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "usd", VS: "rub"}: 90},
+		Errors:    map[testutil.Key]error{{ID: "usd", VS: "usd"}: price.ErrUnknownVS},
+	}
+	r, err := client.NewRouter(map[string]price.PriceClient{"coingecko": coingecko, "binance": binance, "fx": fx},
+		mustRoutes(t, "bitcoin/*=binance,coingecko", "fiat/fiat=fx", "*/*=coingecko"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotes, err := r.GetQuotes(context.Background(), []string{"bitcoin", "ethereum", "usd"}, []string{"usd", "rub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := quotes.Prices()
+	if got["bitcoin"]["usd"] != 100 || got["bitcoin"]["rub"] != 9000 || got["ethereum"]["rub"] != 900 || got["usd"]["rub"] != 90 {
+		t.Fatalf("unexpected quotes: %v", got)
+	}
+	if q := quotes[price.Pair{ID: "bitcoin", VS: "rub"}]; q.Source != "coingecko" {
+		t.Fatalf("fallback pair must be labelled with coingecko, got %q", q.Source)
+	}
+	// usd/usd ушёл в fx и там неизвестен; в coingecko — только нужные пары
+	if fx.Calls() != 2 || coingecko.Calls() != 3 {
+		t.Fatalf("unexpected provider calls: fx=%d coingecko=%d", fx.Calls(), coingecko.Calls())
+	}
+}
+
+// Ошибка провайдера достаётся только его парам, а не всем парам batch-вызова.
+func TestRouter_GetPairs_PerPairErrors(t *testing.T) {
+	coingecko := &testutil.FakePriceClient{Errors: map[testutil.Key]error{
+		{ID: "ethereum", VS: "usd"}: &price.RateLimitError{},
+	}}
+	binance := &testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{{ID: "bitcoin", VS: "usd"}: 100},
+		Errors:    map[testutil.Key]error{{ID: "bitcoin", VS: "eur"}: fmt.Errorf("%w: bitcoin", price.ErrBadPayload)},
+	}
+	r, err := client.NewRouter(map[string]price.PriceClient{"coingecko": coingecko, "binance": binance},
+		mustRoutes(t, "bitcoin/*=binance", "*/*=coingecko"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}, {ID: "bitcoin", VS: "eur"}, {ID: "ethereum", VS: "usd"}}
+	quotes, err := r.GetPairs(context.Background(), pairs)
+	if quotes[pairs[0]].Price != 100 {
+		t.Fatalf("unexpected quotes: %v", quotes)
+	}
+	if got := price.ErrorFor(err, pairs[0]); got != nil {
+		t.Fatalf("served pair must have no error, got %v", got)
+	}
+	if got := price.ErrorFor(err, pairs[1]); !errors.Is(got, price.ErrBadPayload) || errors.Is(got, price.ErrRateLimited) {
+		t.Fatalf("bitcoin->eur: want only binance's bad payload, got %v", got)
+	}
+	if got := price.ErrorFor(err, pairs[2]); !errors.Is(got, price.ErrRateLimited) || errors.Is(got, price.ErrBadPayload) {
+		t.Fatalf("ethereum->usd: want only coingecko's rate limit, got %v", got)
+	}
+}
+
+func TestRouter_RejectsUnknownProvider(t *testing.T) {
+	_, err := client.NewRouter(map[string]price.PriceClient{"coingecko": &testutil.FakePriceClient{}},
+		mustRoutes(t, "*/*=kraken"), nil)
+	if err == nil {
+		t.Fatal("want error for route with unknown provider")
+	}
+}
@@ -0,0 +1,251 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/asset"
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/config"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load(nil, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Addr != ":8080" || cfg.CacheBackend() != "memory" || cfg.Cache.FreshFor != time.Minute {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+	if len(cfg.PollerPairs()) != 3 {
+		t.Fatalf("poller must default to rates pairs, got %v", cfg.PollerPairs())
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, `
+http:
+  addr: ":9000"
+  request_timeout: 3s
+cache:
+  fresh_for: 2m
+rates:
+  default_pairs: [bitcoin/eur, ethereum/eur]
+`)
+	cfg, err := config.Load(
+		[]string{"-config", path, "-http-addr", ":9100"},
+		env(map[string]string{"HTTP_ADDR": ":9050", "CACHE_FRESH_FOR": "90s", "POLLER_PAIRS": "bitcoin/usd"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Addr != ":9100" {
+		t.Fatalf("flag must win over env and file, got %q", cfg.HTTP.Addr)
+	}
+	if cfg.Cache.FreshFor != 90*time.Second {
+		t.Fatalf("env must win over file, got %s", cfg.Cache.FreshFor)
+	}
+	if cfg.HTTP.RequestTimeout != 3*time.Second {
+		t.Fatalf("file must win over defaults, got %s", cfg.HTTP.RequestTimeout)
+	}
+	wantRates := config.PairList{{ID: "bitcoin", VS: "eur"}, {ID: "ethereum", VS: "eur"}}
+	if len(cfg.Rates.DefaultPairs) != 2 || cfg.Rates.DefaultPairs[0] != wantRates[0] || cfg.Rates.DefaultPairs[1] != wantRates[1] {
+		t.Fatalf("unexpected rates pairs: %v", cfg.Rates.DefaultPairs)
+	}
+	if got := cfg.PollerPairs(); len(got) != 1 || got[0] != (price.Pair{ID: "bitcoin", VS: "usd"}) {
+		t.Fatalf("unexpected poller pairs: %v", got)
+	}
+}
+
+func TestLoad_RoutingRules(t *testing.T) {
+	path := writeFile(t, "routing:\n  rules: [\"bitcoin/*=binance,kraken\", \"*/*=coingecko\"]\n")
+	cfg, err := config.Load([]string{"-config", path}, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Routing.Rules.String(); got != "bitcoin/*=binance,kraken;*/*=coingecko" {
+		t.Fatalf("unexpected rules from file: %s", got)
+	}
+
+	cfg, err = config.Load([]string{"-config", path}, env(map[string]string{"ROUTING_RULES": "fiat/fiat=fx; */* = coinbase"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Routing.Rules.String(); got != "fiat/fiat=fx;*/*=coinbase" {
+		t.Fatalf("env must replace file rules, got %s", got)
+	}
+}
+
+func TestLoad_ClassFreshness(t *testing.T) {
+	cfg, err := config.Load(nil, env(map[string]string{"CACHE_CLASS_FRESHNESS": "fiat=2h/30m/48h, stablecoin=5m"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.ClassFreshnessList{
+		{Class: asset.Fiat, Freshness: client.Freshness{FreshFor: 2 * time.Hour, StaleFor: 30 * time.Minute, MaxStale: 48 * time.Hour}},
+		{Class: asset.Stablecoin, Freshness: client.Freshness{FreshFor: 5 * time.Minute}},
+	}
+	if !reflect.DeepEqual(cfg.Cache.ClassFreshness, want) {
+		t.Fatalf("unexpected class freshness: %v", cfg.Cache.ClassFreshness)
+	}
+}
+
+func TestLoad_ConfigFileFromEnv(t *testing.T) {
+	path := writeFile(t, "log:\n  level: warn\n")
+	cfg, err := config.Load(nil, env(map[string]string{config.FileEnv: path}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Fatalf("want warn, got %q", cfg.Log.Level)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		wantErr []string
+	}{
+		{
+			name:    "unknown file key",
+			file:    "cache:\n  fresh: 1m\n",
+			wantErr: []string{"field fresh not found"},
+		},
+		{
+			name:    "bad env value",
+			env:     map[string]string{"CACHE_MAX_ENTRIES": "many"},
+			wantErr: []string{"CACHE_MAX_ENTRIES"},
+		},
+		{
+			name:    "bad pair",
+			args:    []string{"-rates-default-pairs", "bitcoin"},
+			wantErr: []string{"invalid pair"},
+		},
+		{
+			name: "all validation errors at once",
+			env:  map[string]string{"CACHE_BACKEND": "tiered", "COINGECKO_RATE": "0", "LOG_LEVEL": "loud"},
+			wantErr: []string{
+				"cache.redis_addr is required",
+				"coingecko.rate must be positive",
+				"log.level must be",
+			},
+		},
+		{
+			name:    "renew interval not below ttl",
+			env:     map[string]string{"REDIS_ADDR": "localhost:6379", "LEADER_RENEW_INTERVAL": "20s"},
+			wantErr: []string{"leader.renew_interval"},
+		},
+		{
+			name:    "renew interval leaves no margin",
+			env:     map[string]string{"REDIS_ADDR": "localhost:6379", "LEADER_RENEW_INTERVAL": "13s"},
+			wantErr: []string{"leader.renew_interval"},
+		},
+		{
+			name:    "bad class freshness",
+			env:     map[string]string{"CACHE_CLASS_FRESHNESS": "fiat=soon"},
+			wantErr: []string{"invalid class freshness"},
+		},
+		{
+			name: "class freshness validation",
+			env:  map[string]string{"CACHE_CLASS_FRESHNESS": "metal=1h,fiat=0s,fiat=1h"},
+			wantErr: []string{
+				`unknown asset class "metal"`,
+				"cache.class_freshness fiat: fresh_for must be positive",
+				`duplicate class "fiat"`,
+			},
+		},
+		{
+			name:    "bad route",
+			env:     map[string]string{"ROUTING_RULES": "bitcoin/usd"},
+			wantErr: []string{"invalid route"},
+		},
+		{
+			name: "routes to unknown or disabled providers",
+			env:  map[string]string{"ROUTING_RULES": "fiat/fiat=fx;*/*=bitstamp", "FX_ENABLED": "false"},
+			wantErr: []string{
+				`unknown provider "bitstamp"`,
+				"provider fx requires fx.enabled",
+			},
+		},
+		{
+			name: "aggregate",
+			env:  map[string]string{"AGGREGATE_PROVIDERS": "binance,aggregate", "AGGREGATE_MIN_SOURCES": "3", "AGGREGATE_METHOD": "mean"},
+			wantErr: []string{
+				`aggregate.providers: unknown provider "aggregate"`,
+				"aggregate.min_sources must be between 1",
+				"aggregate.method must be median or vwap",
+			},
+		},
+		{
+			name: "failover",
+			env:  map[string]string{"FAILOVER_PROVIDERS": "coingecko,failover", "FAILOVER_DECAY": "0", "FAILOVER_DEMOTE_FOR": "0s"},
+			wantErr: []string{
+				`failover.providers: unknown provider "failover"`,
+				"failover.decay must be in (0, 1]",
+				"failover.demote_for must be positive",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file)}, args...)
+			}
+			_, err := config.Load(args, env(tt.env))
+			if err == nil {
+				t.Fatal("want error, got nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q must contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_StringRoundTrip(t *testing.T) {
+	cfg := config.Default()
+	cfg.Cache.NegativeTTL = 45 * time.Second
+
+	path := writeFile(t, cfg.String())
+	got, err := config.Load([]string{"-config", path}, env(nil))
+	if err != nil {
+		t.Fatalf("printed config must load back: %v\n%s", err, cfg)
+	}
+	if got.Cache.NegativeTTL != 45*time.Second || got.String() != cfg.String() {
+		t.Fatalf("round trip mismatch:\n%s\nvs\n%s", got, cfg)
+	}
+}
+
+func TestLoad_ExampleFile(t *testing.T) {
+	cfg, err := config.Load([]string{"-config", "../../config.example.yaml"}, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.String() != config.Default().String() {
+		t.Fatalf("config.example.yaml must match defaults:\n%s", cfg)
+	}
+}
@@ -0,0 +1,99 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/config"
+)
+
+func TestReloadable(t *testing.T) {
+	cur := config.Default()
+	next := config.Default()
+	next.HTTP.Addr = ":9090"
+	next.Cache.Backend = "tiered"
+	next.Cache.FreshFor = 2 * time.Minute
+	next.CoinGecko.Rate = 2
+	next.Rates.DefaultPairs = config.PairList{{ID: "bitcoin", VS: "eur"}}
+	next.Log.Level = "warn"
+
+	got, rejected := config.Reloadable(cur, next)
+	if !slices.Equal(rejected, []string{"http.addr", "cache.backend"}) {
+		t.Fatalf("unexpected rejected fields: %v", rejected)
+	}
+	if got.HTTP.Addr != cur.HTTP.Addr || got.Cache.Backend != cur.Cache.Backend {
+		t.Fatalf("static fields must keep current values, got addr=%q backend=%q", got.HTTP.Addr, got.Cache.Backend)
+	}
+	if got.Cache.FreshFor != 2*time.Minute || got.CoinGecko.Rate != 2 || got.Log.Level != "warn" ||
+		len(got.Rates.DefaultPairs) != 1 || got.Rates.DefaultPairs[0].VS != "eur" {
+		t.Fatalf("reloadable fields must take new values, got %+v", got)
+	}
+
+	if _, rejected := config.Reloadable(cur, cur); len(rejected) != 0 {
+		t.Fatalf("unchanged config must not reject anything, got %v", rejected)
+	}
+}
+
+// Неверное значение поля, требующего перезапуска, отбрасывается вместе с самим
+// изменением и не мешает применить остальные поля файла.
+func TestReloadable_InvalidStaticField(t *testing.T) {
+	path := writeFile(t, "http:\n  addr: \"\"\nlog:\n  level: warn\n")
+	args := []string{"-config", path}
+	if _, err := config.Load(args, env(nil)); err == nil {
+		t.Fatal("Load must reject an empty http.addr")
+	}
+	next, err := config.Read(args, env(nil))
+	if err != nil {
+		t.Fatalf("Read must not validate: %v", err)
+	}
+
+	got, rejected := config.Reloadable(config.Default(), next)
+	if !slices.Equal(rejected, []string{"http.addr"}) {
+		t.Fatalf("unexpected rejected fields: %v", rejected)
+	}
+	if err := got.Validate(); err != nil {
+		t.Fatalf("config with restored static fields must be valid: %v", err)
+	}
+	if got.Log.Level != "warn" {
+		t.Fatalf("reloadable fields must take new values, got level %q", got.Log.Level)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := writeFile(t, "log:\n  level: info\n")
+	var changes atomic.Int64
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- config.WatchFile(ctx, path, 5*time.Millisecond, func() { changes.Add(1) })
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if changes.Load() != 0 {
+		t.Fatalf("unchanged file must not trigger, got %d", changes.Load())
+	}
+	if err := os.WriteFile(path, []byte("log:\n  level: warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for changes.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("file change was not detected")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WatchFile must return nil on cancel, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WatchFile did not stop after cancel")
+	}
+}
@@ -0,0 +1,229 @@
+package leader_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/leader"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+	"go.uber.org/zap"
+)
+
+// memLease — общий для реплик in-memory lease с TTL (аналог ключа в Redis).
+type memLease struct {
+	mu        sync.Mutex
+	holder    string
+	expiresAt time.Time
+	ttl       time.Duration
+}
+
+func (l *memLease) current() string {
+	if time.Now().After(l.expiresAt) {
+		l.holder = ""
+	}
+	return l.holder
+}
+
+// memLock — leader.Lock одной реплики поверх memLease. crashed имитирует падение:
+// реплика перестаёт продлевать и освобождать lease.
+type memLock struct {
+	lease   *memLease
+	id      string
+	mu      sync.Mutex
+	crashed bool
+}
+
+func (l *memLock) ID() string { return l.id }
+
+func (l *memLock) Acquire(context.Context) (bool, error) {
+	l.lease.mu.Lock()
+	defer l.lease.mu.Unlock()
+	if l.lease.current() != "" {
+		return false, nil
+	}
+	l.lease.holder, l.lease.expiresAt = l.id, time.Now().Add(l.lease.ttl)
+	return true, nil
+}
+
+func (l *memLock) Renew(context.Context) (bool, error) {
+	if l.isCrashed() {
+		return true, nil // «мёртвая» реплика ничего не делает, но и не узнаёт об этом
+	}
+	l.lease.mu.Lock()
+	defer l.lease.mu.Unlock()
+	if l.lease.current() != l.id {
+		return false, nil
+	}
+	l.lease.expiresAt = time.Now().Add(l.lease.ttl)
+	return true, nil
+}
+
+func (l *memLock) Release(context.Context) error {
+	if l.isCrashed() {
+		return nil
+	}
+	l.lease.mu.Lock()
+	defer l.lease.mu.Unlock()
+	if l.lease.current() == l.id {
+		l.lease.holder = ""
+	}
+	return nil
+}
+
+func (l *memLock) Holder(context.Context) (string, error) {
+	l.lease.mu.Lock()
+	defer l.lease.mu.Unlock()
+	return l.lease.current(), nil
+}
+
+func (l *memLock) crash() {
+	l.mu.Lock()
+	l.crashed = true
+	l.mu.Unlock()
+}
+
+func (l *memLock) isCrashed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.crashed
+}
+
+var testConfig = leader.Config{TTL: 60 * time.Millisecond, RenewInterval: 10 * time.Millisecond, RetryInterval: 10 * time.Millisecond}
+
+func TestElector_SingleLeaderAndFailoverOnRelease(t *testing.T) {
+	lease := &memLease{ttl: testConfig.TTL}
+	m := testutil.NewRecordingMetrics()
+	a := leader.NewElector("poller", &memLock{lease: lease, id: "a"}, testConfig, m, zap.NewNop().Sugar())
+	b := leader.NewElector("poller", &memLock{lease: lease, id: "b"}, testConfig, nil, zap.NewNop().Sugar())
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	doneA := make(chan struct{})
+	go func() { _ = a.Run(ctxA); close(doneA) }()
+	waitFor(t, a.IsLeader)
+	go func() { _ = b.Run(ctxB) }()
+
+	time.Sleep(3 * testConfig.TTL)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("want only a as leader, got a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+	if m.Gauge("leader:poller") != 1 {
+		t.Fatal("leader gauge must be 1")
+	}
+
+	// a останавливается штатно и освобождает lease
+	cancelA()
+	<-doneA
+	if a.IsLeader() || m.Gauge("leader:poller") != 0 {
+		t.Fatal("stopped replica must not be leader")
+	}
+	waitFor(t, b.IsLeader)
+}
+
+func TestElector_FailoverAfterLeaderCrash(t *testing.T) {
+	lease := &memLease{ttl: testConfig.TTL}
+	lockA := &memLock{lease: lease, id: "a"}
+	a := leader.NewElector("poller", lockA, testConfig, nil, zap.NewNop().Sugar())
+	b := leader.NewElector("poller", &memLock{lease: lease, id: "b"}, testConfig, nil, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = a.Run(ctx) }()
+	waitFor(t, a.IsLeader)
+	go func() { _ = b.Run(ctx) }()
+
+	lockA.crash()
+	waitFor(t, b.IsLeader)
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/leader", nil))
+	var st leader.Status
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if !st.Leader || st.Instance != "b" || st.Holder != "b" || st.Since.IsZero() {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+// stallingLock — memLock, у которого после stall Renew зависает до отмены ctx
+// (Redis не отвечает) или сразу возвращает ошибку.
+type stallingLock struct {
+	*memLock
+	fail    bool
+	stalled chan struct{}
+}
+
+func (l *stallingLock) Renew(ctx context.Context) (bool, error) {
+	select {
+	case <-l.stalled:
+	default:
+		return l.memLock.Renew(ctx)
+	}
+	if l.fail {
+		return false, errors.New("redis: connection refused")
+	}
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestElector_StepsDownBeforeLeaseExpires(t *testing.T) {
+	cfg := testConfig
+	cfg.Margin = 30 * time.Millisecond
+
+	for _, tc := range []struct {
+		name string
+		fail bool
+	}{
+		{name: "renew blocks", fail: false},
+		{name: "renew fails", fail: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lease := &memLease{ttl: cfg.TTL}
+			lock := &stallingLock{memLock: &memLock{lease: lease, id: "a"}, fail: tc.fail, stalled: make(chan struct{})}
+			m := testutil.NewRecordingMetrics()
+			a := leader.NewElector("poller", lock, cfg, m, zap.NewNop().Sugar())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() { _ = a.Run(ctx); close(done) }()
+			defer func() { cancel(); <-done }()
+			waitFor(t, a.IsLeader)
+
+			// последнее продление было не позже этого момента, lease живёт ещё TTL после него
+			start := time.Now()
+			close(lock.stalled)
+			waitFor(t, func() bool { return !a.IsLeader() })
+
+			if elapsed := time.Since(start); elapsed >= cfg.TTL {
+				t.Fatalf("leadership lasted %v, want less than TTL %v", elapsed, cfg.TTL)
+			}
+			if holder, _ := lock.Holder(ctx); holder != "a" {
+				t.Fatalf("lease must still be held by a when it steps down, got %q", holder)
+			}
+			if m.Gauge("leader:poller") != 0 {
+				t.Fatal("leader gauge must be 0")
+			}
+			if a.Status().Leader {
+				t.Fatal("status must not report leadership")
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,171 @@
+package poller_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/client"
+	"github.com/boxdancer/go-currency-tracker/internal/poller"
+	"github.com/boxdancer/go-currency-tracker/internal/price"
+	"github.com/boxdancer/go-currency-tracker/tests/testutil"
+	"go.uber.org/zap"
+)
+
+// fakeRefresher считает вызовы Refresh и возвращает err.
+type fakeRefresher struct {
+	mu    sync.Mutex
+	calls int
+	pairs []price.Pair
+	err   error
+}
+
+func (f *fakeRefresher) Refresh(_ context.Context, pairs []price.Pair) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	f.pairs = pairs
+	return f.err
+}
+
+func (f *fakeRefresher) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestPoller_RefreshesUntilCancelled(t *testing.T) {
+	r := &fakeRefresher{}
+	m := testutil.NewRecordingMetrics()
+	pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}}
+	p := poller.New(r, poller.Config{Pairs: pairs, Interval: 10 * time.Millisecond, Jitter: 0.5}, m, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for r.Calls() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("want at least 3 refreshes, got %d", r.Calls())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run must return nil on cancel, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+	if m.Count("poller:ok") < 3 {
+		t.Fatalf("want refreshes in metrics, got %d", m.Count("poller:ok"))
+	}
+	if len(r.pairs) != 1 || r.pairs[0] != pairs[0] {
+		t.Fatalf("unexpected pairs: %v", r.pairs)
+	}
+}
+
+func TestPoller_LagGrowsOnFailure(t *testing.T) {
+	r := &fakeRefresher{err: errors.New("upstream down")}
+	m := testutil.NewRecordingMetrics()
+	p := poller.New(r, poller.Config{Pairs: []price.Pair{{ID: "bitcoin", VS: "usd"}}, Interval: 10 * time.Millisecond}, m, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if m.Count("poller:fail") == 0 || m.Count("poller:ok") != 0 {
+		t.Fatalf("want only failed refreshes, got ok=%d fail=%d", m.Count("poller:ok"), m.Count("poller:fail"))
+	}
+	if lag := m.Gauge("poller_lag"); lag < 0.02 {
+		t.Fatalf("lag must grow without successful refreshes, got %vs", lag)
+	}
+}
+
+func TestCachedPriceClient_RefreshWarmsCache(t *testing.T) {
+	backend := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{
+		Responses: map[testutil.Key]float64{
+			{ID: "bitcoin", VS: "usd"}:  100,
+			{ID: "ethereum", VS: "usd"}: 10,
+		},
+	}}
+	cached := client.NewCachedPriceClient(backend, testutil.NewFakeCache(), nil)
+	ctx := context.Background()
+
+	pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}, {ID: "ethereum", VS: "usd"}}
+	if err := cached.Refresh(ctx, pairs); err != nil {
+		t.Fatal(err)
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("want 1 batch call, got %d", backend.Calls())
+	}
+	if v, err := cached.GetPrice(ctx, "ethereum", "usd"); err != nil || v != 10 {
+		t.Fatalf("want 10, got %v, %v", v, err)
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("refreshed pair must be a cache hit, got %d backend calls", backend.Calls())
+	}
+}
+
+type staticLeader bool
+
+func (l staticLeader) IsLeader() bool { return bool(l) }
+
+func TestPoller_SkipsRefreshWhenNotLeader(t *testing.T) {
+	r := &fakeRefresher{}
+	p := poller.New(r, poller.Config{Pairs: []price.Pair{{ID: "bitcoin", VS: "usd"}}, Interval: 5 * time.Millisecond},
+		nil, zap.NewNop().Sugar(), poller.WithLeader(staticLeader(false)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+	if r.Calls() != 0 {
+		t.Fatalf("follower must not refresh, got %d calls", r.Calls())
+	}
+}
+
+// Сложив полномочия, реплика перестаёт публиковать своё отставание, а снова став
+// лидером — считает его с момента избрания, а не с прошлого срока.
+func TestPoller_LagResetsOnStepDown(t *testing.T) {
+	r := &fakeRefresher{err: errors.New("upstream down")}
+	m := testutil.NewRecordingMetrics()
+	var leading atomic.Bool
+	leading.Store(true)
+	p := poller.New(r, poller.Config{Pairs: []price.Pair{{ID: "bitcoin", VS: "usd"}}, Interval: 5 * time.Millisecond},
+		m, zap.NewNop().Sugar(), poller.WithLeader(leaderFunc(leading.Load)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { _ = p.Run(ctx); close(done) }()
+	defer func() { cancel(); <-done }()
+
+	waitGauge(t, m, func(lag float64) bool { return lag >= 0.05 })
+	leading.Store(false)
+	waitGauge(t, m, func(lag float64) bool { return lag == 0 })
+	leading.Store(true)
+	waitGauge(t, m, func(lag float64) bool { return lag > 0 })
+	if lag := m.Gauge("poller_lag"); lag >= 0.05 {
+		t.Fatalf("lag must restart on re-election, got %vs", lag)
+	}
+}
+
+type leaderFunc func() bool
+
+func (f leaderFunc) IsLeader() bool { return f() }
+
+func waitGauge(t *testing.T, m *testutil.RecordingMetrics, cond func(float64) bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond(m.Gauge("poller_lag")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected poller lag %vs", m.Gauge("poller_lag"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -3,10 +3,13 @@ package service_test
 import (
     "context"
     "errors"
+    "fmt"
     "testing"
     "time"
 
+    "github.com/boxdancer/go-currency-tracker/internal/client"
     "github.com/boxdancer/go-currency-tracker/internal/currency"
+    "github.com/boxdancer/go-currency-tracker/internal/price"
     "github.com/boxdancer/go-currency-tracker/tests/testutil"
 )
 
@@ -27,20 +30,18 @@ func mustNotHaveIDs(t *testing.T, got map[string]map[string]float64, ids ...stri
     }
 }
 
-// helper to copy a map[string]string (to avoid accidental sharing between subtests)
-func copyPairs(in map[string]string) map[string]string {
-    out := make(map[string]string, len(in))
-    for k, v := range in {
-        out[k] = v
-    }
+// helper to copy a []price.Pair (to avoid accidental sharing between subtests)
+func copyPairs(in []price.Pair) []price.Pair {
+    out := make([]price.Pair, len(in))
+    copy(out, in)
     return out
 }
 
 func TestService_GetMany(t *testing.T) {
-    basePairs := map[string]string{
-        "bitcoin":  "usd",
-        "ethereum": "usd",
-        "usd":      "rub",
+    basePairs := []price.Pair{
+        {ID: "bitcoin", VS: "usd"},
+        {ID: "ethereum", VS: "usd"},
+        {ID: "usd", VS: "rub"},
     }
 
     type fields struct {
@@ -49,7 +50,7 @@ func TestService_GetMany(t *testing.T) {
     type args struct {
         // ctxFactory создаёт контекст и возвращает cancel, чтобы мы могли defer cancel() внутри t.Run
         ctxFactory func() (context.Context, context.CancelFunc)
-        pairs      map[string]string
+        pairs      []price.Pair
     }
 
     tests := []struct {
@@ -59,6 +60,7 @@ func TestService_GetMany(t *testing.T) {
         wantErr    bool
         mustHave   []string
         mustAbsent []string
+        wantFailed []price.Pair
     }{
         {
             name: "all success",
@@ -99,6 +101,25 @@ func TestService_GetMany(t *testing.T) {
             wantErr:    true,
             mustHave:   []string{"bitcoin", "ethereum"},
             mustAbsent: []string{"usd"},
+            wantFailed: []price.Pair{{ID: "usd", VS: "rub"}},
+        },
+        {
+            name: "one id in many currencies",
+            fields: fields{fake: &testutil.FakePriceClient{
+                Responses: map[testutil.Key]float64{
+                    {ID: "bitcoin", VS: "usd"}: 100.0,
+                    {ID: "bitcoin", VS: "eur"}: 90.0,
+                    {ID: "bitcoin", VS: "rub"}: 9000.0,
+                },
+            }},
+            args: args{
+                ctxFactory: func() (context.Context, context.CancelFunc) {
+                    return context.Background(), func() {}
+                },
+                pairs: currency.CrossPairs([]string{"bitcoin"}, []string{"usd", "eur", "rub"}),
+            },
+            wantErr:  false,
+            mustHave: []string{"bitcoin"},
         },
         {
             name: "context cancelled",
@@ -113,10 +134,7 @@ func TestService_GetMany(t *testing.T) {
                 ctxFactory: func() (context.Context, context.CancelFunc) {
                     return context.WithTimeout(context.Background(), 50*time.Millisecond)
                 },
-                pairs: map[string]string{
-                    "bitcoin":  "usd",
-                    "ethereum": "usd",
-                },
+                pairs: currency.CrossPairs([]string{"bitcoin", "ethereum"}, []string{"usd"}),
             },
             wantErr: true,
         },
@@ -130,7 +148,8 @@ func TestService_GetMany(t *testing.T) {
             defer cancel()
 
             svc := currency.NewService(tt.fields.fake)
-            got, err := svc.GetMany(ctx, tt.args.pairs)
+            results := svc.GetMany(ctx, tt.args.pairs)
+            got, err := results.Prices(), results.Err()
 
             if tt.wantErr && err == nil {
                 t.Fatalf("expected error, got nil")
@@ -145,6 +164,27 @@ func TestService_GetMany(t *testing.T) {
                 }
             }
 
+            if !tt.wantErr {
+                for _, p := range tt.args.pairs {
+                    if _, ok := got[p.ID][p.VS]; !ok {
+                        t.Fatalf("expected price for %s", p)
+                    }
+                }
+            }
+
+            if tt.wantFailed != nil {
+                failed := results.Failed()
+                if len(failed) != len(tt.wantFailed) {
+                    t.Fatalf("want %d failed pairs, got %d: %v", len(tt.wantFailed), len(failed), err)
+                }
+                for i, r := range failed {
+                    var pe *currency.PairError
+                    if r.Pair != tt.wantFailed[i] || !errors.As(r.Err, &pe) || pe.Pair != r.Pair {
+                        t.Fatalf("unexpected failed result: %+v", r)
+                    }
+                }
+            }
+
             if len(tt.mustHave) > 0 {
                 mustHaveIDs(t, got, tt.mustHave...)
             }
@@ -154,3 +194,232 @@ func TestService_GetMany(t *testing.T) {
         })
     }
 }
+
+func TestService_GetMany_Batch(t *testing.T) {
+    fake := &testutil.FakeBatchClient{FakePriceClient: testutil.FakePriceClient{
+        Responses: map[testutil.Key]float64{
+            {ID: "bitcoin", VS: "usd"}:  100.0,
+            {ID: "bitcoin", VS: "eur"}:  90.0,
+            {ID: "ethereum", VS: "usd"}: 10.0,
+        },
+    }}
+    svc := currency.NewService(fake)
+
+    results := svc.GetMany(context.Background(), currency.CrossPairs([]string{"bitcoin", "ethereum"}, []string{"usd", "eur"}))
+    got := results.Prices()
+    failed := results.Failed()
+    if len(failed) != 1 || failed[0].Pair != (price.Pair{ID: "ethereum", VS: "eur"}) || !errors.Is(failed[0].Err, price.ErrUnknownVS) {
+        t.Fatalf("expected only ethereum->eur to fail with ErrUnknownVS, got %+v", failed)
+    }
+    if fake.Calls() != 1 {
+        t.Fatalf("want 1 batch call, got %d", fake.Calls())
+    }
+    if got["bitcoin"]["eur"] != 90 || got["ethereum"]["usd"] != 10 {
+        t.Fatalf("unexpected prices: %v", got)
+    }
+    if _, ok := got["ethereum"]["eur"]; ok {
+        t.Fatalf("did not expect ethereum->eur in results")
+    }
+}
+
+// Список пар, не образующий ids × vs, не должен расширяться до декартова произведения.
+func TestService_GetMany_PairList(t *testing.T) {
+    backend := &testutil.FakePriceClient{
+        Responses: map[testutil.Key]float64{
+            {ID: "bitcoin", VS: "usd"}:  100.0,
+            {ID: "ethereum", VS: "usd"}: 10.0,
+            {ID: "usd", VS: "rub"}:      90.0,
+        },
+    }
+    svc := currency.NewService(client.NewCachedPriceClient(backend, testutil.NewFakeCache(), nil))
+
+    pairs := []price.Pair{{ID: "bitcoin", VS: "usd"}, {ID: "ethereum", VS: "usd"}, {ID: "usd", VS: "rub"}}
+    results := svc.GetMany(context.Background(), pairs)
+    if err := results.Err(); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if results[2].Price != 90 {
+        t.Fatalf("unexpected results: %+v", results)
+    }
+    if calls := backend.Calls(); calls != 3 {
+        t.Fatalf("want 3 backend calls (one per requested pair), got %d", calls)
+    }
+}
+
+// Каждая пара batch-вызова получает свою ошибку, а не ошибку соседней пары.
+func TestService_GetMany_MixedErrors(t *testing.T) {
+    backend := &testutil.FakePriceClient{
+        Responses: map[testutil.Key]float64{
+            {ID: "bitcoin", VS: "usd"}: 100.0,
+        },
+        Errors: map[testutil.Key]error{
+            {ID: "ethereum", VS: "usd"}: &price.RateLimitError{RetryAfter: 5 * time.Second},
+            {ID: "solana", VS: "usd"}:   fmt.Errorf("%w: solana", price.ErrUnknownID),
+            {ID: "tron", VS: "usd"}:     fmt.Errorf("%w: truncated json", price.ErrBadPayload),
+        },
+    }
+    svc := currency.NewService(client.NewCachedPriceClient(backend, testutil.NewFakeCache(), nil))
+
+    results := svc.GetMany(context.Background(), currency.CrossPairs([]string{"bitcoin", "ethereum", "solana", "tron"}, []string{"usd"}))
+    if results[0].Err != nil || results[0].Price != 100 {
+        t.Fatalf("bitcoin->usd should succeed, got %+v", results[0])
+    }
+    want := []struct {
+        err   error
+        other []error
+    }{
+        {price.ErrRateLimited, []error{price.ErrUnknownID, price.ErrBadPayload}},
+        {price.ErrUnknownID, []error{price.ErrRateLimited, price.ErrBadPayload}},
+        {price.ErrBadPayload, []error{price.ErrRateLimited, price.ErrUnknownID}},
+    }
+    for i, w := range want {
+        r := results[i+1]
+        if !errors.Is(r.Err, w.err) {
+            t.Fatalf("%s: want %v, got %v", r.Pair, w.err, r.Err)
+        }
+        for _, other := range w.other {
+            if errors.Is(r.Err, other) {
+                t.Fatalf("%s: error %v must not match %v", r.Pair, r.Err, other)
+            }
+        }
+    }
+    var rl *price.RateLimitError
+    if !errors.As(results[1].Err, &rl) || rl.RetryAfter != 5*time.Second {
+        t.Fatalf("ethereum->usd must keep its Retry-After, got %v", results[1].Err)
+    }
+}
+
+// Ошибка одной пары не должна отменять медленные запросы остальных:
+// падающая пара отвечает сразу, успешная — заметно позже.
+func TestService_GetMany_NoSiblingCancel(t *testing.T) {
+    newBackend := func() *testutil.FakePriceClient {
+        return &testutil.FakePriceClient{
+            Responses: map[testutil.Key]float64{
+                {ID: "bitcoin", VS: "usd"}: 100.0,
+            },
+            Errors: map[testutil.Key]error{
+                {ID: "ethereum", VS: "usd"}: testutil.Err("provider failure"),
+            },
+            Delays: map[testutil.Key]time.Duration{
+                {ID: "bitcoin", VS: "usd"}: 50 * time.Millisecond,
+            },
+        }
+    }
+    tests := []struct {
+        name   string
+        client func() price.PriceClient
+    }{
+        {"per pair", func() price.PriceClient { return newBackend() }},
+        {"batch", func() price.PriceClient {
+            return client.NewCachedPriceClient(newBackend(), testutil.NewFakeCache(), nil)
+        }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            svc := currency.NewService(tt.client())
+
+            results := svc.GetMany(context.Background(), currency.CrossPairs([]string{"bitcoin", "ethereum"}, []string{"usd"}))
+            if results[0].Err != nil || results[0].Price != 100 {
+                t.Fatalf("bitcoin->usd should succeed, got %+v", results[0])
+            }
+            if results[1].Err == nil {
+                t.Fatalf("ethereum->usd should fail")
+            }
+        })
+    }
+}
+
+func TestService_GetMany_ConcurrencyLimit(t *testing.T) {
+    newBackend := func() *testutil.FakePriceClient {
+        fake := &testutil.FakePriceClient{Responses: map[testutil.Key]float64{}, Delay: 5 * time.Millisecond}
+        for i := 0; i < 20; i++ {
+            fake.Responses[testutil.Key{ID: fmt.Sprintf("coin%d", i), VS: "usd"}] = float64(i)
+        }
+        return fake
+    }
+    ids := make([]string, 0, 20)
+    for i := 0; i < 20; i++ {
+        ids = append(ids, fmt.Sprintf("coin%d", i))
+    }
+    tests := []struct {
+        name   string
+        client func(*testutil.FakePriceClient) price.PriceClient
+    }{
+        {"per pair", func(f *testutil.FakePriceClient) price.PriceClient { return f }},
+        // лимит доходит до запросов пар внутри batch-вызова
+        {"batch", func(f *testutil.FakePriceClient) price.PriceClient {
+            return client.NewCachedPriceClient(f, testutil.NewFakeCache(), nil)
+        }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fake := newBackend()
+            svc := currency.NewService(tt.client(fake), currency.WithConcurrency(3))
+
+            results := svc.GetMany(context.Background(), currency.CrossPairs(ids, []string{"usd"}))
+            if err := results.Err(); err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := fake.MaxInFlight(); got > 3 {
+                t.Fatalf("want at most 3 concurrent calls, got %d", got)
+            }
+        })
+    }
+}
+
+// Медленная пара упирается в собственный таймаут и не съедает общий бюджет запроса.
+func TestService_GetMany_PairTimeout(t *testing.T) {
+    fake := &testutil.FakePriceClient{
+        Responses: map[testutil.Key]float64{
+            {ID: "bitcoin", VS: "usd"}:  100.0,
+            {ID: "ethereum", VS: "usd"}: 10.0,
+        },
+        Delays: map[testutil.Key]time.Duration{
+            {ID: "ethereum", VS: "usd"}: time.Second,
+        },
+    }
+    svc := currency.NewService(fake, currency.WithPairTimeout(30*time.Millisecond))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+    defer cancel()
+    start := time.Now()
+    results := svc.GetMany(ctx, currency.CrossPairs([]string{"bitcoin", "ethereum"}, []string{"usd"}))
+    if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+        t.Fatalf("GetMany took %v, pair timeout not applied", elapsed)
+    }
+    if results[0].Err != nil {
+        t.Fatalf("bitcoin->usd should succeed, got %v", results[0].Err)
+    }
+    if !errors.Is(results[1].Err, context.DeadlineExceeded) {
+        t.Fatalf("ethereum->usd: want deadline exceeded, got %v", results[1].Err)
+    }
+}
+
+// В batch-вызове таймаут действует на каждую пару и отсчитывается после ожидания очереди:
+// медленная пара не должна утянуть за собой быстрые, стоящие за ней.
+func TestService_GetMany_PairTimeoutBatch(t *testing.T) {
+    fake := &testutil.FakePriceClient{
+        Responses: map[testutil.Key]float64{},
+        Delay:     20 * time.Millisecond,
+        Delays: map[testutil.Key]time.Duration{
+            {ID: "slowcoin", VS: "usd"}: time.Second,
+        },
+    }
+    ids := []string{"slowcoin"}
+    for i := 0; i < 4; i++ {
+        id := fmt.Sprintf("coin%d", i)
+        ids = append(ids, id)
+        fake.Responses[testutil.Key{ID: id, VS: "usd"}] = float64(i)
+    }
+    svc := currency.NewService(client.NewCachedPriceClient(fake, testutil.NewFakeCache(), nil),
+        currency.WithConcurrency(1), currency.WithPairTimeout(50*time.Millisecond))
+
+    results := svc.GetMany(context.Background(), currency.CrossPairs(ids, []string{"usd"}))
+    if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+        t.Fatalf("slowcoin->usd: want deadline exceeded, got %v", results[0].Err)
+    }
+    // быстрые пары вместе с очередью дольше таймаута, но каждая укладывается в свой
+    if err := results[1:].Err(); err != nil {
+        t.Fatalf("fast pairs must succeed: %v", err)
+    }
+}
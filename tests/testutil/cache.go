@@ -0,0 +1,120 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/cache"
+)
+
+// ErrCacheMiss возвращается FakeCache.Get, если ключа нет.
+var ErrCacheMiss = cache.ErrMiss
+
+// FakeCache — потокобезопасная in-memory реализация cache.Cache без TTL.
+// TTL, переданные в SetWithTTL, только запоминаются (см. TTL).
+type FakeCache struct {
+	mu    sync.Mutex
+	data  map[string]string
+	ttls  map[string]time.Duration
+	gets  int
+	mgets int
+	msets int
+}
+
+func NewFakeCache() *FakeCache {
+	return &FakeCache{data: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (c *FakeCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gets++
+	v, ok := c.data[key]
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	return v, nil
+}
+
+func (c *FakeCache) MGet(_ context.Context, keys []string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mgets++
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if v, ok := c.data[key]; ok {
+			out[key] = v
+		}
+	}
+	return out, nil
+}
+
+func (c *FakeCache) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = string(value)
+	delete(c.ttls, key)
+	return nil
+}
+
+func (c *FakeCache) SetWithTTL(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return cache.ErrInvalidTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = string(value)
+	c.ttls[key] = ttl
+	return nil
+}
+
+func (c *FakeCache) MSet(_ context.Context, values map[string][]byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return cache.ErrInvalidTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msets++
+	for key, value := range values {
+		c.data[key] = string(value)
+		c.ttls[key] = ttl
+	}
+	return nil
+}
+
+func (c *FakeCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	delete(c.ttls, key)
+	return nil
+}
+
+// Len возвращает количество ключей в кэше.
+func (c *FakeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// TTL возвращает TTL, с которым key был записан через SetWithTTL или MSet (0 — записан через Set).
+func (c *FakeCache) TTL(key string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttls[key]
+}
+
+// MSets возвращает число вызовов MSet.
+func (c *FakeCache) MSets() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.msets
+}
+
+// Calls возвращает число вызовов Get и MGet.
+func (c *FakeCache) Calls() (gets, mgets int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gets, c.mgets
+}
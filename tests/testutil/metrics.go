@@ -0,0 +1,141 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/observability"
+)
+
+// RecordingMetrics — observability.Metrics, считающий интересные тестам события.
+// Остальные методы делегируются noop-реализации.
+type RecordingMetrics struct {
+	observability.Metrics
+
+	mu     sync.Mutex
+	counts map[string]int
+	gauges map[string]float64
+}
+
+func NewRecordingMetrics() *RecordingMetrics {
+	return &RecordingMetrics{Metrics: observability.NewNoopMetrics(), counts: make(map[string]int), gauges: make(map[string]float64)}
+}
+
+// Count возвращает, сколько раз было записано событие name.
+func (m *RecordingMetrics) Count(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[name]
+}
+
+// Gauge возвращает последнее значение gauge name.
+func (m *RecordingMetrics) Gauge(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gauges[name]
+}
+
+func (m *RecordingMetrics) set(name string, v float64) {
+	m.mu.Lock()
+	m.gauges[name] = v
+	m.mu.Unlock()
+}
+
+func (m *RecordingMetrics) inc(name string) {
+	m.mu.Lock()
+	m.counts[name]++
+	m.mu.Unlock()
+}
+
+func (m *RecordingMetrics) CacheHit()  { m.inc("cache_hit") }
+func (m *RecordingMetrics) CacheMiss() { m.inc("cache_miss") }
+
+func (m *RecordingMetrics) NegativeCacheHit() { m.inc("negative_hit") }
+
+func (m *RecordingMetrics) BackendCoalesced() { m.inc("coalesced") }
+
+func (m *RecordingMetrics) StaleServed(reason string) { m.inc("stale:" + reason) }
+
+func (m *RecordingMetrics) BackendRetry(provider, reason string) {
+	m.inc("retry:" + provider + ":" + reason)
+}
+
+func (m *RecordingMetrics) SetBreakerState(name string, state int) {
+	m.set("breaker:"+name, float64(state))
+}
+
+func (m *RecordingMetrics) RateLimitRejected(name string) {
+	m.inc("ratelimit_rejected:" + name)
+}
+
+func (m *RecordingMetrics) CacheEviction(cache, reason string) {
+	m.inc("evict:" + cache + ":" + reason)
+}
+
+func (m *RecordingMetrics) CacheTierLookup(tier string, hit bool) {
+	if hit {
+		m.inc("tier:" + tier + ":hit")
+	} else {
+		m.inc("tier:" + tier + ":miss")
+	}
+}
+
+func (m *RecordingMetrics) SetInvalidationSubscribed(subscribed bool) {
+	v := 0.0
+	if subscribed {
+		v = 1
+	}
+	m.set("invalidation_subscribed", v)
+}
+
+func (m *RecordingMetrics) ObservePollerRefresh(_ time.Duration, success bool) {
+	if success {
+		m.inc("poller:ok")
+	} else {
+		m.inc("poller:fail")
+	}
+}
+
+func (m *RecordingMetrics) SetPollerLag(d time.Duration) {
+	m.set("poller_lag", d.Seconds())
+}
+
+func (m *RecordingMetrics) SetLeader(name string, leader bool) {
+	v := 0.0
+	if leader {
+		v = 1
+	}
+	m.set("leader:"+name, v)
+}
+
+func (m *RecordingMetrics) RouteResult(provider, result string) {
+	m.inc("route:" + provider + ":" + result)
+}
+
+func (m *RecordingMetrics) ObserveAggregateSpread(spread float64) {
+	m.set("aggregate_spread", spread)
+}
+
+func (m *RecordingMetrics) AggregateOutlier(provider string) {
+	m.inc("aggregate_outlier:" + provider)
+}
+
+func (m *RecordingMetrics) AggregateNoQuorum() { m.inc("aggregate_no_quorum") }
+
+func (m *RecordingMetrics) FailoverResult(provider, result string) {
+	m.inc("failover:" + provider + ":" + result)
+}
+
+func (m *RecordingMetrics) SetProviderHealth(provider string, score float64) {
+	m.set("health:"+provider, score)
+}
+
+func (m *RecordingMetrics) ProviderDemoted(provider string) { m.inc("demoted:" + provider) }
+
+func (m *RecordingMetrics) ConfigReload(success bool) {
+	if success {
+		m.inc("config_reload:ok")
+	} else {
+		m.inc("config_reload:fail")
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
+
+	"github.com/boxdancer/go-currency-tracker/internal/price"
 )
 
 // Key для Responses/Errors
@@ -13,17 +16,56 @@ type Key struct{ ID, VS string }
 type FakePriceClient struct {
 	Responses map[Key]float64
 	Errors    map[Key]error
-	Delay     time.Duration // опциональная задержка для имитации сети
+	Delay     time.Duration         // опциональная задержка для имитации сети
+	Delays    map[Key]time.Duration // задержка для отдельных пар (перекрывает Delay)
+
+	calls       atomic.Int64
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
 }
 
 func (f *FakePriceClient) GetPrice(ctx context.Context, id, vs string) (float64, error) {
-	if f.Delay > 0 {
+	f.calls.Add(1)
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		m := f.maxInFlight.Load()
+		if n <= m || f.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+
+	if err := f.wait(ctx, f.delay(id, vs)); err != nil {
+		return 0, err
+	}
+	return f.lookup(id, vs)
+}
+
+// Calls возвращает количество вызовов backend (GetPrice и GetPrices).
+func (f *FakePriceClient) Calls() int64 { return f.calls.Load() }
+
+// MaxInFlight возвращает максимальное число одновременных вызовов GetPrice.
+func (f *FakePriceClient) MaxInFlight() int64 { return f.maxInFlight.Load() }
+
+func (f *FakePriceClient) delay(id, vs string) time.Duration {
+	if d, ok := f.Delays[Key{ID: id, VS: vs}]; ok {
+		return d
+	}
+	return f.Delay
+}
+
+func (f *FakePriceClient) wait(ctx context.Context, d time.Duration) error {
+	if d > 0 {
 		select {
-		case <-time.After(f.Delay):
+		case <-time.After(d):
 		case <-ctx.Done():
-			return 0, ctx.Err()
+			return ctx.Err()
 		}
 	}
+	return nil
+}
+
+func (f *FakePriceClient) lookup(id, vs string) (float64, error) {
 	k := Key{ID: id, VS: vs}
 	if err, ok := f.Errors[k]; ok {
 		return 0, err
@@ -34,5 +76,53 @@ func (f *FakePriceClient) GetPrice(ctx context.Context, id, vs string) (float64,
 	return 0, fmt.Errorf("no mock for %s:%s", id, vs)
 }
 
+// FakeBatchClient — FakePriceClient, дополнительно реализующий price.BatchClient.
+// Пары с ошибками или без мока в ответ GetPrices не попадают; BatchErr
+// имитирует отказ всего запроса.
+type FakeBatchClient struct {
+	FakePriceClient
+	BatchErr error
+}
+
+func (f *FakeBatchClient) GetPrices(ctx context.Context, ids, vs []string) (map[string]map[string]float64, error) {
+	f.calls.Add(1)
+	if err := f.wait(ctx, f.Delay); err != nil {
+		return nil, err
+	}
+	if f.BatchErr != nil {
+		return nil, f.BatchErr
+	}
+	out := make(map[string]map[string]float64)
+	for _, id := range ids {
+		for _, v := range vs {
+			p, err := f.lookup(id, v)
+			if err != nil {
+				continue
+			}
+			if out[id] == nil {
+				out[id] = make(map[string]float64)
+			}
+			out[id][v] = p
+		}
+	}
+	return out, nil
+}
+
+// FakeQuoteClient — FakePriceClient, дополнительно реализующий price.QuoteClient:
+// котировки помечены Source, объём берётся из Volumes.
+type FakeQuoteClient struct {
+	FakePriceClient
+	Source  string
+	Volumes map[Key]float64
+}
+
+func (f *FakeQuoteClient) GetQuote(ctx context.Context, id, vs string) (price.Quote, error) {
+	p, err := f.GetPrice(ctx, id, vs)
+	if err != nil {
+		return price.Quote{}, err
+	}
+	return price.Quote{ID: id, VS: vs, Price: p, Source: f.Source, Volume: f.Volumes[Key{ID: id, VS: vs}], FetchedAt: time.Now()}, nil
+}
+
 // Утилита для быстрого создания ошибок
 func Err(msg string) error { return errors.New(msg) }